@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/config"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/securityhubcollector"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+// CollectCommand collects findings into the output file, without uploading unless asked to
+type CollectCommand struct {
+	Upload bool `long:"upload" description:"Upload the output file to the S3 bucket after collecting."`
+}
+
+// Execute collects findings and optionally uploads them
+func (c *CollectCommand) Execute(_ []string) error {
+	if c.Upload && options.S3Bucket == "" {
+		return fmt.Errorf("--upload requires an S3 bucket")
+	}
+	return runCollection(c.Upload)
+}
+
+// UploadCommand uploads an existing findings file to S3
+type UploadCommand struct {
+	File string `long:"file" description:"Findings file to upload. Defaults to the output file."`
+	Date string `long:"date" description:"Collection date (YYYY-MM-DD) used in the S3 key. Defaults to today."`
+}
+
+// Execute uploads the findings file under the daily key for the collection date
+func (c *UploadCommand) Execute(_ []string) error {
+	if options.S3Bucket == "" {
+		return fmt.Errorf("an S3 bucket is required to upload findings")
+	}
+
+	fileName := c.File
+	if fileName == "" {
+		fileName = options.OutputFileName
+	}
+
+	date := time.Now()
+	if c.Date != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", c.Date)
+		if err != nil {
+			return fmt.Errorf("could not parse date %q: %v", c.Date, err)
+		}
+	}
+
+	return writeFindingsToS3(context.Background(), fileName, date)
+}
+
+// ValidateTeamMapCommand loads and validates the team sources and account overrides
+type ValidateTeamMapCommand struct{}
+
+// Execute loads the team map and prints a short description of it
+func (c *ValidateTeamMapCommand) Execute(_ []string) error {
+	accountsToTeams, _, _, err := loadTeams()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("team map is valid: %d teams, %d accounts\n", len(teams.TeamNames(accountsToTeams)), len(accountsToTeams))
+	return nil
+}
+
+// ReportCommand summarizes an existing findings file
+type ReportCommand struct {
+	File       string `long:"file" description:"Findings file to summarize. Defaults to the output file."`
+	FileFormat string `long:"file-format" choice:"tsv" choice:"csv" choice:"jsonl" description:"Format of the findings file. Defaults to --output-format for the output file, and is otherwise guessed from the file extension."`
+	Format     string `long:"format" choice:"text" choice:"json" default:"text" description:"Output format for the report."`
+}
+
+// Execute reads the findings file and writes its summary to stdout
+func (c *ReportCommand) Execute(_ []string) error {
+	fileName := c.File
+	if fileName == "" {
+		fileName = options.OutputFileName
+	}
+	fileFormat := findingsFileFormat(fileName, c.FileFormat, c.File == "")
+
+	records, err := securityhubcollector.ReadFindingRecords(fileName, fileFormat)
+	if err != nil {
+		return err
+	}
+
+	builder := summary.NewBuilder()
+	for _, record := range records {
+		builder.Add(record.SummaryRow())
+	}
+	s := builder.Summary()
+
+	if c.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	}
+	return s.WriteText(os.Stdout)
+}
+
+// ConvertCommand rewrites a findings file in another format
+type ConvertCommand struct {
+	File       string `long:"file" description:"Findings file to convert. Defaults to the output file."`
+	FileFormat string `long:"file-format" choice:"tsv" choice:"csv" choice:"jsonl" description:"Format of the findings file. Defaults to --output-format for the output file, and is otherwise guessed from the file extension."`
+	To         string `long:"to" required:"true" choice:"tsv" choice:"csv" choice:"jsonl" description:"Format to convert to."`
+	Out        string `long:"out" required:"true" description:"File to write the converted findings to."`
+}
+
+// Execute converts the findings file
+func (c *ConvertCommand) Execute(_ []string) error {
+	fileName := c.File
+	if fileName == "" {
+		fileName = options.OutputFileName
+	}
+	fileFormat := findingsFileFormat(fileName, c.FileFormat, c.File == "")
+
+	err := securityhubcollector.ConvertFindingsFile(fileName, fileFormat, c.Out, c.To)
+	if err != nil {
+		return err
+	}
+	slog.Info("converted findings file", "file", fileName, "format", fileFormat, "out", c.Out, "to", c.To)
+	return nil
+}
+
+// findingsFileFormat returns the format to read a findings file in: fileFormat if it is given,
+// the output format for the Collector's own output, whose default name ends in .csv although it
+// is tab-delimited, and otherwise the format the file's extension suggests
+func findingsFileFormat(fileName, fileFormat string, ownOutput bool) string {
+	switch {
+	case fileFormat != "":
+		return fileFormat
+	case ownOutput:
+		return options.OutputFormat
+	default:
+		return securityhubcollector.FormatFromFileName(fileName)
+	}
+}
+
+// PrintConfigCommand prints the effective configuration
+type PrintConfigCommand struct{}
+
+// Execute writes the value and source of every option to stdout as YAML
+func (c *PrintConfigCommand) Execute(_ []string) error {
+	return config.WriteYAML(os.Stdout, config.Effective(parser, configFile))
+}
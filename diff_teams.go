@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+// DiffTeamsCommand compares the team maps loaded from two team sources
+type DiffTeamsCommand struct {
+	From           string `long:"from" required:"true" description:"Team source to compare from, e.g. file:team_map.json, team-map or teams-api."`
+	To             string `long:"to" required:"true" description:"Team source to compare to, e.g. file:team_map.json, team-map or teams-api."`
+	Format         string `long:"format" choice:"text" choice:"json" default:"text" description:"Output format for the differences."`
+	MaxDifferences int    `long:"max-differences" default:"0" description:"Exit with an error if more than this many accounts differ. Use -1 to never fail."`
+}
+
+// Execute loads both team sources, writes their differences to stdout and fails if
+// there are more differences than allowed
+func (c *DiffTeamsCommand) Execute(_ []string) error {
+	from, err := loadTeamSource(c.From)
+	if err != nil {
+		return err
+	}
+	to, err := loadTeamSource(c.To)
+	if err != nil {
+		return err
+	}
+
+	diff := teams.DiffTeamMaps(from, to)
+
+	if c.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(diff)
+	} else {
+		err = diff.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write team map differences: %v", err)
+	}
+
+	if c.MaxDifferences >= 0 && diff.Count() > c.MaxDifferences {
+		return fmt.Errorf("%d accounts differ between %s and %s, more than the allowed %d", diff.Count(), c.From, c.To, c.MaxDifferences)
+	}
+	return nil
+}
+
+// loadTeamSource parses a team source and loads it using the team settings from the global options
+func loadTeamSource(spec string) (map[teams.Account]string, error) {
+	source, err := teams.ParseSource(spec)
+	if err != nil {
+		return nil, err
+	}
+	accountsToTeams, err := source.Load(teamSourceConfig())
+	if err != nil {
+		return nil, fmt.Errorf("could not load team source %s: %v", source, err)
+	}
+	return accountsToTeams, nil
+}
+
+// teamSourceConfig returns the team source settings from the global options
+func teamSourceConfig() teams.SourceConfig {
+	return teams.SourceConfig{
+		Base64TeamMap:   options.Base64TeamMap,
+		TeamsAPIBaseURL: options.TeamsAPIBaseURL,
+		TeamsAPIKey:     options.TeamsAPIKey,
+		RolePath:        options.CollectorRolePath,
+	}
+}
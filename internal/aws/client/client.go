@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
@@ -13,21 +14,28 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
-// MakeSecurityHubClient creates a SecurityHub client
-func MakeSecurityHubClient(secHubRegion, roleArn string) (*securityhub.Client, error) {
-	// if roleArn is provided, this will contain the cross-account credentials provider
-	// if not, this will be nil and the default credentials provider chain will be used
-	var appCreds aws.CredentialsProvider
-	if roleArn != "" {
-		stsConfig, err := config.LoadDefaultConfig(context.TODO())
-		if err != nil {
-			panic("unable to load SDK config for STS: %s" + err.Error())
-		}
-		stsClient := sts.NewFromConfig(stsConfig)
-		appCreds = stscreds.NewAssumeRoleProvider(stsClient, roleArn)
+// MakeAssumeRoleProvider creates a caching credentials provider that assumes roleArn,
+// passing externalID if it is set
+func MakeAssumeRoleProvider(secHubRegion, roleArn, externalID string, optFns ...func(*config.LoadOptions) error) (aws.CredentialsProvider, error) {
+	stsOptFns := append([]func(*config.LoadOptions) error{config.WithRegion(stsRegion(secHubRegion, roleArn))}, optFns...)
+	stsConfig, err := config.LoadDefaultConfig(context.TODO(), stsOptFns...)
+	if err != nil {
+		return nil, err
 	}
+	stsClient := sts.NewFromConfig(stsConfig)
+	provider := stscreds.NewAssumeRoleProvider(stsClient, roleArn, func(o *stscreds.AssumeRoleOptions) {
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	return aws.NewCredentialsCache(provider), nil
+}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(secHubRegion), config.WithCredentialsProvider(appCreds))
+// MakeSecurityHubClientWithCredentials creates a SecurityHub client using the given credentials
+// provider, or the default credentials provider chain if it is nil
+func MakeSecurityHubClientWithCredentials(secHubRegion string, creds aws.CredentialsProvider, optFns ...func(*config.LoadOptions) error) (*securityhub.Client, error) {
+	secHubOptFns := append([]func(*config.LoadOptions) error{config.WithRegion(secHubRegion), config.WithCredentialsProvider(creds)}, optFns...)
+	cfg, err := config.LoadDefaultConfig(context.TODO(), secHubOptFns...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to load SDK config for SecurityHub: %s", err)
 	}
@@ -37,10 +45,37 @@ func MakeSecurityHubClient(secHubRegion, roleArn string) (*securityhub.Client, e
 
 // MakeS3Uploader creates an S3 upload manager
 func MakeS3Uploader(region string) (*manager.Uploader, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+	client, err := MakeS3Client(region)
 	if err != nil {
 		return nil, fmt.Errorf("unable to load SDK config for S3 uploader: %s", err)
 	}
-	uploader := manager.NewUploader(s3.NewFromConfig(cfg))
+	uploader := manager.NewUploader(client)
 	return uploader, nil
 }
+
+// MakeS3Client creates an S3 client. Path-style addressing is used when a custom endpoint
+// is configured through optFns, since custom endpoints rarely support bucket subdomains.
+func MakeS3Client(region string, optFns ...func(*config.LoadOptions) error) (*s3.Client, error) {
+	s3OptFns := append([]func(*config.LoadOptions) error{config.WithRegion(region)}, optFns...)
+	cfg, err := config.LoadDefaultConfig(context.TODO(), s3OptFns...)
+	if err != nil {
+		return nil, err
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = cfg.BaseEndpoint != nil
+	})
+	return client, nil
+}
+
+// stsRegion picks the region to call STS in when assuming roleArn. The Security Hub region is
+// used when it is in the role's partition; otherwise the partition's default region is used.
+func stsRegion(secHubRegion, roleArn string) string {
+	parsed, err := arn.Parse(roleArn)
+	if err != nil || RegionPartition(secHubRegion) == parsed.Partition {
+		return secHubRegion
+	}
+	if region := PartitionDefaultRegion(parsed.Partition); region != "" {
+		return region
+	}
+	return secHubRegion
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/aws/smithy-go"
+)
+
+// ErrorClass classifies an error for logs and metrics: the error code of an AWS API error, e.g.
+// AccessDeniedException, or "canceled", "timeout" or "unknown" for other errors
+func ErrorClass(err error) string {
+	var apiErr smithy.APIError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &apiErr):
+		return apiErr.ErrorCode()
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import "strings"
+
+// AWS partitions the Collector knows how to reach
+const (
+	PartitionAWS      = "aws"
+	PartitionGovCloud = "aws-us-gov"
+	PartitionChina    = "aws-cn"
+)
+
+// partitionDefaultRegions is the region used for a partition's STS and Security Hub
+// endpoints when no region in that partition has been configured
+var partitionDefaultRegions = map[string]string{
+	PartitionAWS:      "us-east-1",
+	PartitionGovCloud: "us-gov-west-1",
+	PartitionChina:    "cn-north-1",
+}
+
+// IsKnownPartition reports whether the partition is one the Collector supports
+func IsKnownPartition(partition string) bool {
+	_, ok := partitionDefaultRegions[partition]
+	return ok
+}
+
+// PartitionDefaultRegion returns the default region for a partition, or an empty string
+// if the partition is unknown
+func PartitionDefaultRegion(partition string) string {
+	return partitionDefaultRegions[partition]
+}
+
+// RegionPartition returns the partition that a region belongs to
+func RegionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionGovCloud
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionChina
+	default:
+		return PartitionAWS
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// logLevels maps the --log-level choices to slog levels
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// setupLogging sets the default logger to write to stderr in the format and at the level given by
+// --log-format and --log-level
+func setupLogging() {
+	handlerOptions := &slog.HandlerOptions{Level: logLevels[options.LogLevel]}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, handlerOptions)
+	if options.LogFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, handlerOptions)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// fatal logs msg, err and any other attributes at the error level and exits
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{"error", err}, args...)...)
+	os.Exit(1)
+}
@@ -2,64 +2,132 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/catalog"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/config"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/feeds"
 	"github.com/Enterprise-CMCS/security-hub-collector/pkg/helpers"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/manifest"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/metrics"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/preflight"
 	"github.com/Enterprise-CMCS/security-hub-collector/pkg/securityhubcollector"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/severity"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/sla"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/suppression"
 	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/tracing"
 
 	flag "github.com/jessevdk/go-flags"
+	"go.opentelemetry.io/otel/attribute"
 
-	"log"
+	"log/slog"
 )
 
 // Options describes the command line options available.
 type Options struct {
-	OutputFileName     string   `short:"o" long:"output" env:"OUTPUT_FILE" required:"false" description:"File to direct output to." default:"SecurityHub-Findings.csv"`
-	S3Region           string   `short:"s" long:"s3-region" env:"AWS_REGION" required:"false" description:"AWS region to use for s3 uploads."`
-	SecurityHubRegions []string `short:"r" long:"sechub-regions" required:"false" default:"us-east-1" default:"us-west-2" description:"AWS regions to use for Security Hub findings."`
-	S3Bucket           string   `short:"b" long:"s3-bucket" required:"false" env:"S3_BUCKET" description:"S3 bucket to use to upload results. Optional, if not provided, results will not be uploaded to S3."`
-	S3Key              string   `short:"k" long:"s3-key" required:"false" env:"S3_KEY" description:"S3 bucket key, or path, to use to upload results."`
-	Base64TeamMap      string   `short:"m" long:"team-map" required:"false" env:"BASE64_TEAM_MAP" description:"Base64 encoded JSON containing team to account mappings."`
-	TeamsAPIBaseURL    string   `long:"teams-api-base-url" required:"false" env:"TEAMS_API_BASE_URL" description:"Base URL of the Teams API, which provides team to account mappings"`
-	TeamsAPIKey        string   `long:"teams-api-key" required:"false" env:"TEAMS_API_KEY" description:"API key for the Teams API, which provides team to account mappings"`
-	CollectorRolePath  string   `long:"role-path" required:"false" env:"COLLECTOR_ROLE_PATH" description:"Path of the AWS IAM cross-account role that allows the Collector to access Security Hub"`
+	ConfigFile           string   `long:"config" required:"false" env:"CONFIG_FILE" description:"Path to a YAML config file of option values, keyed by long option name. Flags and environment variables take precedence over the file."`
+	OutputFileName       string   `short:"o" long:"output" env:"OUTPUT_FILE" required:"false" description:"File to direct output to." default:"SecurityHub-Findings.csv"`
+	S3Region             string   `short:"s" long:"s3-region" env:"AWS_REGION" required:"false" description:"AWS region to use for s3 uploads."`
+	SecurityHubRegions   []string `short:"r" long:"sechub-regions" required:"false" env:"SECHUB_REGIONS" env-delim:"," default:"us-east-1" default:"us-west-2" description:"AWS regions to use for Security Hub findings."`
+	S3Bucket             string   `short:"b" long:"s3-bucket" required:"false" env:"S3_BUCKET" description:"S3 bucket to use to upload results. Optional, if not provided, results will not be uploaded to S3."`
+	S3Key                string   `short:"k" long:"s3-key" required:"false" env:"S3_KEY" description:"S3 bucket key, or path, to use to upload results."`
+	Base64TeamMap        string   `short:"m" long:"team-map" required:"false" env:"BASE64_TEAM_MAP" description:"Base64 encoded JSON containing team to account mappings."`
+	TeamsAPIBaseURL      string   `long:"teams-api-base-url" required:"false" env:"TEAMS_API_BASE_URL" description:"Base URL of the Teams API, which provides team to account mappings"`
+	TeamsAPIKey          string   `long:"teams-api-key" required:"false" env:"TEAMS_API_KEY" secret:"true" description:"API key for the Teams API, which provides team to account mappings"`
+	TeamSources          []string `long:"team-source" required:"false" env:"TEAM_SOURCES" env-delim:"," description:"Ordered team sources to merge, as [<mode>=]<source> where mode is first-wins (default), override or union, e.g. teams-api or override=file:fixes.json. Repeatable."`
+	TeamMapSourceColumn  bool     `long:"team-map-source-column" required:"false" env:"TEAM_MAP_SOURCE_COLUMN" description:"Add a Team Map Source column naming the team source each row's account mapping came from. On by default with more than one --team-source."`
+	CollectorRolePath    string   `long:"role-path" required:"false" env:"COLLECTOR_ROLE_PATH" description:"Path of the AWS IAM cross-account role that allows the Collector to access Security Hub"`
+	AccountOverrides     string   `long:"account-overrides" required:"false" env:"ACCOUNT_OVERRIDES_FILE" description:"Path to a JSON file of per-account overrides (role name, partition, external ID, regions) layered on top of the team data."`
+	TeamTagKey           string   `long:"team-tag-key" required:"false" env:"TEAM_TAG_KEY" description:"Resource tag key (e.g. cms:team) whose value overrides the account's team for a finding. Optional."`
+	ResourceColumns      bool     `long:"resource-columns" required:"false" env:"RESOURCE_COLUMNS" description:"Add Resource Name, Resource Owner, Resource Image and Publicly Exposed columns."`
+	OwnerTagKey          string   `long:"owner-tag-key" required:"false" env:"OWNER_TAG_KEY" default:"Owner" description:"Resource tag key whose value is written to the Resource Owner column. Matched regardless of case."`
+	ValidateTeamTag      bool     `long:"validate-team-tag" required:"false" env:"VALIDATE_TEAM_TAG" description:"Only honor team tag values that match a team name from the team map."`
+	OutputFormat         string   `long:"output-format" required:"false" env:"OUTPUT_FORMAT" choice:"tsv" choice:"csv" choice:"jsonl" default:"tsv" description:"Format of the output file. QuickSight ingests tsv."`
+	DryRun               bool     `long:"dry-run" required:"false" env:"DRY_RUN" description:"Print the collection plan (accounts, regions, role ARNs, output file and S3 key) without calling Security Hub or S3."`
+	DryRunFormat         string   `long:"dry-run-format" required:"false" choice:"table" choice:"json" default:"table" description:"Format of the dry run plan."`
+	RecordStates         []string `long:"record-state" required:"false" env:"RECORD_STATES" env-delim:"," choice:"ACTIVE" choice:"ARCHIVED" default:"ACTIVE" description:"Record states of the findings to collect. Repeatable."`
+	ExcludeWorkflow      []string `long:"exclude-workflow-status" required:"false" env:"EXCLUDE_WORKFLOW_STATUSES" env-delim:"," choice:"NEW" choice:"NOTIFIED" choice:"RESOLVED" choice:"SUPPRESSED" default:"RESOLVED" description:"Workflow statuses of the findings to leave out. Repeatable."`
+	Teams                []string `long:"team" required:"false" env:"SELECT_TEAMS" env-delim:"," description:"Only collect findings for teams matching this name or glob pattern, e.g. dev-*. Repeatable."`
+	Accounts             []string `long:"account" required:"false" env:"SELECT_ACCOUNTS" env-delim:"," description:"Only collect findings for AWS account IDs matching this ID or glob pattern. Repeatable."`
+	Regions              []string `long:"region" required:"false" env:"SELECT_REGIONS" env-delim:"," description:"Only collect findings in Security Hub regions matching this name or glob pattern, out of the regions that would otherwise be collected. Repeatable."`
+	SummaryFileName      string   `long:"summary-output" required:"false" env:"SUMMARY_FILE" description:"File to write the JSON run summary to. Defaults to the output file name with a _summary.json suffix."`
+	ManifestFileName     string   `long:"manifest-output" required:"false" env:"MANIFEST_FILE" description:"File to write the JSON run manifest to. Defaults to the output file name with a _manifest.json suffix."`
+	Sanitize             string   `long:"sanitize" required:"false" env:"SANITIZE" choice:"unicode" choice:"ascii" default:"unicode" description:"How to clean up field values: unicode replaces only control characters and the output delimiter with spaces; ascii replaces everything outside printable ASCII, as older versions did."`
+	Newlines             string   `long:"newlines" required:"false" env:"NEWLINES" choice:"space" choice:"escape" choice:"keep" description:"How to write newlines in field values with --sanitize unicode: as spaces, escaped as \\n, or kept. Defaults to keep for jsonl and space otherwise."`
+	Transliterate        bool     `long:"transliterate" required:"false" env:"TRANSLITERATE" description:"With --sanitize unicode, replace accented letters and typographic punctuation with their closest ASCII equivalents."`
+	DedupKey             string   `long:"dedup-key" required:"false" env:"DEDUP_KEY" choice:"control" choice:"generator" description:"Deduplicate rows across regions and products, keeping the most recently updated: control (control ID, resource ID and account) or generator (generator ID and resource ID). Adds Duplicate Count and Duplicate Regions columns."`
+	ComplianceColumns    bool     `long:"compliance-columns" required:"false" env:"COMPLIANCE_COLUMNS" description:"Add Control ID, Associated Standards and Related Requirements columns."`
+	ControlCatalog       string   `long:"control-catalog" required:"false" env:"CONTROL_CATALOG_FILE" description:"Path to a JSON file mapping Security Hub control IDs to your control catalog, e.g. NIST 800-53. Adds Catalog Controls and Catalog Families columns."`
+	VulnerabilityColumns bool     `long:"vulnerability-columns" required:"false" env:"VULNERABILITY_COLUMNS" description:"Add CVE IDs, CVSS Score, Vulnerable Packages, Fixed In Versions and Exploit Available columns."`
+	EPSSFeed             string   `long:"epss-feed" required:"false" env:"EPSS_FEED_FILE" description:"Path to a FIRST EPSS scores CSV file, optionally gzipped. Adds an EPSS Score column, whose scores from the file take precedence over the ones products report."`
+	KEVFeed              string   `long:"kev-feed" required:"false" env:"KEV_FEED_FILE" description:"Path to the CISA Known Exploited Vulnerabilities JSON catalog. Adds a Known Exploited column."`
+	SeverityOverrides    string   `long:"severity-overrides" required:"false" env:"SEVERITY_OVERRIDES_FILE" description:"Path to a JSON file of rules remapping severities by product, control ID, environment and team. Adds Effective Severity and Severity Rule columns."`
+	SLAPolicy            string   `long:"sla-policy" required:"false" env:"SLA_POLICY_FILE" description:"Path to a JSON file of remediation SLA days per severity, optionally overridden per team or environment. Adds Age Days, SLA Due Date, SLA Days Remaining and SLA Overdue columns."`
+	Suppressions         string   `long:"suppressions" required:"false" env:"SUPPRESSIONS_FILE" description:"Path to a JSON file of accepted risk rules. Matching rows are excluded or tagged with Risk Status and Suppression Rule columns; expired rules are reported."`
+	RedactedOutput       string   `long:"redacted-output" required:"false" env:"REDACTED_OUTPUT_FILE" description:"Also write a redacted copy of the findings to this file, with account IDs, resource IDs and ARNs replaced by pseudonyms, for sharing outside the program. It is never uploaded to S3."`
+	RedactMethod         string   `long:"redact-method" required:"false" env:"REDACT_METHOD" choice:"hmac" choice:"mask" default:"hmac" description:"How to redact identifiers: hmac hashes them with --redact-key, so they line up across runs; mask numbers them, so they only line up within a run."`
+	RedactKey            string   `long:"redact-key" required:"false" env:"REDACT_KEY" secret:"true" description:"Key for --redact-method hmac."`
+	RedactDropColumns    []string `long:"redact-drop" required:"false" env:"REDACT_DROP_COLUMNS" env-delim:"," description:"Column to leave empty in the redacted output, e.g. Description or Remediation URL. Repeatable."`
+	ObservedColumns      bool     `long:"observed-columns" required:"false" env:"OBSERVED_COLUMNS" description:"Add First Observed At and Last Observed At columns."`
+	RunIDColumn          bool     `long:"run-id-column" required:"false" env:"RUN_ID_COLUMN" description:"Add a Run ID column linking every row to the run manifest."`
+	MetricsAddr          string   `long:"metrics-addr" required:"false" env:"METRICS_ADDR" description:"Address, e.g. :9090, to serve Prometheus metrics on at /metrics while collecting."`
+	MetricsTextfile      string   `long:"metrics-textfile" required:"false" env:"METRICS_TEXTFILE" description:"File to write Prometheus metrics to at the end of the run, for the node exporter's textfile collector."`
+	PushgatewayURL       string   `long:"pushgateway-url" required:"false" env:"PUSHGATEWAY_URL" description:"Prometheus Pushgateway URL to push metrics to at the end of the run."`
+	SubsetOutputFileName string   `long:"subset-output" required:"false" env:"SUBSET_OUTPUT_FILE" description:"File to direct output to when --team, --account or --region selects a subset. Defaults to the output file name with a _subset suffix."`
+	UploadSubset         bool     `long:"upload-subset" required:"false" env:"UPLOAD_SUBSET" description:"Upload the output of a --team, --account or --region subset run to the daily S3 key. Subset runs are not uploaded by default, since they would replace the full day's findings."`
+	TraceExporter        string   `long:"trace-exporter" required:"false" env:"TRACE_EXPORTER" choice:"none" choice:"stdout" choice:"otlp" default:"none" description:"Where to export OpenTelemetry spans of collection runs: stdout, or otlp to send them to the endpoint in OTEL_EXPORTER_OTLP_ENDPOINT."`
+	Preflight            bool     `long:"preflight" required:"false" env:"PREFLIGHT" description:"Check access to every account and the S3 bucket before collecting, and stop if any check fails. Checking the bucket writes a marker object to --preflight-s3-key."`
+	PreflightS3Key       string   `long:"preflight-s3-key" required:"false" env:"PREFLIGHT_S3_KEY" description:"Key of the marker object that preflight checks write to the S3 bucket to check write access. The object is overwritten on every check. Defaults to preflight/security-hub-collector-preflight.txt."`
+	NoPreflightS3Write   bool     `long:"no-preflight-s3-write" required:"false" env:"NO_PREFLIGHT_S3_WRITE" description:"Don't write the marker object to the S3 bucket in preflight checks, leaving write access to the bucket unchecked."`
+	LogFormat            string   `long:"log-format" required:"false" env:"LOG_FORMAT" choice:"text" choice:"json" default:"text" description:"Format of the log messages written to stderr. Use json for CloudWatch Logs Insights."`
+	LogLevel             string   `long:"log-level" required:"false" env:"LOG_LEVEL" choice:"debug" choice:"info" choice:"warn" choice:"error" default:"info" description:"Minimum level of the log messages to write. debug logs every page of findings."`
 }
 
 var options Options
 
-// WriteFindingsToS3 - Writes the finding results file to an S3 bucket
-func writeFindingsToS3() error {
+// version is the collector version recorded in run manifests. It is set at build time with
+// -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// parser parses the CLI options into options
+var parser *flag.Parser
+
+// configFile holds the values read from the --config file, if any
+var configFile config.File
+
+// WriteFindingsToS3 - Writes the finding results file to an S3 bucket, under the daily key for the given date
+func writeFindingsToS3(ctx context.Context, fileName string, date time.Time) error {
+	return uploadToS3(ctx, fileName, dailyS3Key(date), "findings")
+}
+
+// uploadToS3 uploads a file to the S3 bucket under the given key. what describes the file in logs.
+func uploadToS3(ctx context.Context, fileName, key, what string) (err error) {
+	ctx, span := tracing.Start(ctx, "upload to S3",
+		attribute.String("kind", what), attribute.String("bucket", options.S3Bucket), attribute.String("key", key))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	s3uploader, err := client.MakeS3Uploader(options.S3Region)
 	if err != nil {
 		return err
 	}
-	// use Outfile name as the key by default
-	key := options.OutputFileName
-	// if the passed in key exists, use that
-	if options.S3Key != "" {
-		key = options.S3Key
-	}
-
-	// Carve up things and throw in timestamp in the key.
-	// Use a daily timestamp so that multiple runs in the same day will overwrite
-	// the previous run's file with updated results for that day
-	current := time.Now()
-	suffix := current.Format("01-02-2006")
-	ext := path.Ext(key)
-	fn := strings.TrimSuffix(key, ext)
-	key = fn + "_" + suffix + ext
 
 	// open our local file for reading
-	f, err := os.Open(options.OutputFileName) //nolint
+	f, err := os.Open(fileName) //nolint
 	if err != nil {
 		return err
 	}
@@ -77,87 +145,523 @@ func writeFindingsToS3() error {
 		Key:    aws.String(key),
 		Body:   f,
 	}
-	_, err = s3uploader.Upload(context.TODO(), upParams)
+	_, err = s3uploader.Upload(ctx, upParams)
 	if err != nil {
 		return err
 	}
-	log.Printf("successfully uploaded findings to s3://%v/%v", options.S3Bucket, key)
+	slog.Info("uploaded file to S3", "kind", what, "file", fileName, "bucket", options.S3Bucket, "key", key)
 
 	return nil
 }
 
-// collectFindings is doing the bulk of our work here; it reads in the team map from the Teams API,
-// builds the HubCollector object, writes headers to the output file, and processes findings
-// depending on the definitions in the team map and the CLI options.
-func collectFindings(secHubRegions []string) error {
+// dailyS3Key returns the S3 key that findings collected on the given date are uploaded to
+func dailyS3Key(date time.Time) string {
+	// use Outfile name as the key by default
+	key := options.OutputFileName
+	// if the passed in key exists, use that
+	if options.S3Key != "" {
+		key = options.S3Key
+	}
+
+	// Carve up things and throw in timestamp in the key.
+	// Use a daily timestamp so that multiple runs in the same day will overwrite
+	// the previous run's file with updated results for that day
+	suffix := date.Format("01-02-2006")
+	ext := path.Ext(key)
+	fn := strings.TrimSuffix(key, ext)
+	return fn + "_" + suffix + ext
+}
+
+// manifestS3Key returns the S3 key that the run manifest is uploaded to, next to the findings
+// collected on the given date
+func manifestS3Key(date time.Time) string {
+	key := dailyS3Key(date)
+	return strings.TrimSuffix(key, path.Ext(key)) + "_manifest.json"
+}
+
+// summaryS3Key returns the S3 key that the run summary is uploaded to, next to the findings
+// collected on the given date
+func summaryS3Key(date time.Time) string {
+	key := dailyS3Key(date)
+	return strings.TrimSuffix(key, path.Ext(key)) + "_summary.json"
+}
+
+// loadTeams reads in the team map from the team sources selected by the CLI options and applies
+// any account overrides. It also returns the team sources, even if loading them fails, so that
+// callers can report them without parsing them again.
+func loadTeams() (map[teams.Account]string, *teams.AccountOverrides, []teams.MergeSource, error) {
+	sources, err := teamSources()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	merged, err := teams.LoadAndMerge(sources, teamSourceConfig())
+	if err != nil {
+		return nil, nil, sources, fmt.Errorf("could not load team sources: %v", err)
+	}
+	for _, conflict := range merged.Conflicts {
+		slog.Warn("team source conflict", "account_id", conflict.AccountID, "conflict", conflict.String())
+	}
+	accountsToTeams := merged.AccountsToTeams
+
+	var overrides *teams.AccountOverrides
+	if options.AccountOverrides != "" {
+		overrides, err = teams.ParseAccountOverridesFile(options.AccountOverrides)
+		if err != nil {
+			return nil, nil, sources, fmt.Errorf("could not parse account overrides file: %v", err)
+		}
+		accountsToTeams, err = overrides.Apply(accountsToTeams)
+		if err != nil {
+			return nil, nil, sources, fmt.Errorf("could not apply account overrides: %v", err)
+		}
+	}
+
+	return accountsToTeams, overrides, sources, nil
+}
+
+// teamSources returns the ordered team sources to merge. If no --team-source is given, the
+// single source selected by --team-map or --teams-api-base-url is used.
+func teamSources() ([]teams.MergeSource, error) {
+	if len(options.TeamSources) > 0 {
+		var sources []teams.MergeSource
+		for _, spec := range options.TeamSources {
+			source, err := teams.ParseMergeSource(spec)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+		}
+		return sources, nil
+	}
+
 	// Check which source to use for team data and validate required fields
 	if options.Base64TeamMap == "" && options.TeamsAPIBaseURL == "" {
-		return fmt.Errorf("either team map file or Teams API base URL must be specified")
+		return nil, fmt.Errorf("either team map file, Teams API base URL or team sources must be specified")
 	}
 	if options.Base64TeamMap != "" && options.TeamsAPIBaseURL != "" {
-		return fmt.Errorf("both team map file and Teams API base URL specified; please use only one source of team map data")
+		return nil, fmt.Errorf("both team map file and Teams API base URL specified; use --team-source to combine more than one source of team map data")
 	}
 	if options.TeamsAPIBaseURL != "" && options.TeamsAPIKey == "" {
-		return fmt.Errorf("Teams API key required when using Teams API")
+		return nil, fmt.Errorf("Teams API key required when using Teams API")
+	}
+
+	kind := teams.SourceTeamsAPI
+	if options.Base64TeamMap != "" {
+		kind = teams.SourceTeamMap
+	}
+	return []teams.MergeSource{{Source: teams.Source{Kind: kind}, Mode: teams.MergeFirstWins}}, nil
+}
+
+// sanitizePolicy returns the sanitization policy for the output format, adjusted by --sanitize,
+// --newlines and --transliterate
+func sanitizePolicy() *securityhubcollector.SanitizePolicy {
+	policy := securityhubcollector.DefaultSanitizePolicy(options.OutputFormat)
+	policy.Mode = options.Sanitize
+	if options.Newlines != "" {
+		policy.Newlines = options.Newlines
+	}
+	policy.Transliterate = options.Transliterate
+	return &policy
+}
+
+// collectFindings is doing the bulk of our work here; it reads in the team map from the Teams API,
+// builds the HubCollector object, writes headers to the output file, and processes findings
+// depending on the definitions in the team map and the CLI options. Only the jobs matched by
+// selector are collected.
+func collectFindings(ctx context.Context, secHubRegions []string, selector jobSelector, m *manifest.Manifest, runMetrics *metrics.Metrics) error {
+	_, span := tracing.Start(ctx, "load teams")
+	accountsToTeams, overrides, sources, err := loadTeams()
+	for _, source := range sources {
+		m.TeamSources = append(m.TeamSources, source.String())
+	}
+	span.SetAttributes(attribute.StringSlice("team_sources", m.TeamSources), tracing.AttrAccounts.Int(len(accountsToTeams)))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	m.TeamMapHash = teams.SnapshotHash(accountsToTeams)
+
+	jobs, err := selector.selectJobs(collectionJobs(accountsToTeams, overrides, secHubRegions))
+	if err != nil {
+		return err
+	}
+	m.Accounts.Planned = len(jobAccounts(jobs))
+
+	if options.Preflight {
+		err = runPreflight(jobAccounts(jobs), overrides, jobRegions(jobs), preflight.DefaultConcurrency, "", "table")
+		if err != nil {
+			return err
+		}
+	}
+
+	h := securityhubcollector.HubCollector{
+		TeamTagKey:               options.TeamTagKey,
+		TeamMapSourceColumn:      options.TeamMapSourceColumn || len(sources) > 1,
+		ObservedColumns:          options.ObservedColumns,
+		ResourceColumns:          options.ResourceColumns,
+		OwnerTagKey:              options.OwnerTagKey,
+		Format:                   options.OutputFormat,
+		RecordStates:             options.RecordStates,
+		ExcludedWorkflowStatuses: options.ExcludeWorkflow,
+		DedupKey:                 options.DedupKey,
+		ComplianceColumns:        options.ComplianceColumns,
+		VulnerabilityColumns:     options.VulnerabilityColumns,
+		Sanitize:                 sanitizePolicy(),
+		Summary:                  summary.NewBuilder(),
+		Metrics:                  runMetrics,
+	}
+	if runMetrics != nil {
+		h.ClientOptions = append(h.ClientOptions, awsconfig.WithAPIOptions(runMetrics.APIOptions()))
+	}
+	if options.RunIDColumn {
+		h.RunID = m.RunID
+	}
+	if options.ValidateTeamTag {
+		h.KnownTeams = teams.TeamNames(accountsToTeams)
+	}
+	if options.Suppressions != "" {
+		h.Suppressions, err = suppression.ParseRulesFile(options.Suppressions)
+		if err != nil {
+			return err
+		}
+	}
+	if options.ControlCatalog != "" {
+		h.Catalog, err = catalog.ParseCatalogFile(options.ControlCatalog)
+		if err != nil {
+			return err
+		}
+	}
+	if options.EPSSFeed != "" {
+		h.EPSS, err = feeds.LoadEPSS(options.EPSSFeed)
+		if err != nil {
+			return err
+		}
+	}
+	if options.KEVFeed != "" {
+		h.KEV, err = feeds.LoadKEV(options.KEVFeed)
+		if err != nil {
+			return err
+		}
+	}
+	if options.SeverityOverrides != "" {
+		h.SeverityOverrides, err = severity.ParseOverridesFile(options.SeverityOverrides)
+		if err != nil {
+			return err
+		}
+	}
+	if options.SLAPolicy != "" {
+		h.SLA, err = sla.ParsePolicyFile(options.SLAPolicy)
+		if err != nil {
+			return err
+		}
 	}
 
-	h := securityhubcollector.HubCollector{}
-	err := h.Initialize(options.OutputFileName)
+	if options.RedactedOutput != "" {
+		h.Redaction = &securityhubcollector.RedactionProfile{
+			Method:      options.RedactMethod,
+			Key:         []byte(options.RedactKey),
+			DropColumns: options.RedactDropColumns,
+		}
+		h.RedactedOutputFileName = options.RedactedOutput
+	}
+
+	err = h.Initialize(outputFileName(selector))
 	if err != nil {
-		log.Fatalf("could not initialize HubCollector: %v", err)
+		fatal("could not initialize HubCollector", err)
 	}
 
 	// flush the buffer and close the file when the function completes.
 	defer func() {
 		ferr := h.FlushAndClose()
 		if ferr != nil {
-			log.Fatalf("could not flush buffer and close output file: %v", err)
+			fatal("could not flush buffer and close output file", ferr)
 		}
 	}()
 
-	var accountsToTeams map[teams.Account]string
-
-	// either get the map from the team map file or from the Teams API, depending on the specified CLI flags
-	if options.Base64TeamMap != "" {
-		accountsToTeams, err = teams.ParseTeamMap(options.Base64TeamMap)
+	for i, job := range jobs {
+		err = h.GetFindingsAndWriteToOutput(ctx, job.Region, job.Team, job.Account)
 		if err != nil {
-			log.Fatalf("could not parse team map file: %v", err)
+			m.AddAccountResult(job.AccountID, err)
+			runMetrics.AccountFailed()
+			return fmt.Errorf("could not get findings for account %v in %v: %v", job.AccountID, job.Region, err)
 		}
-	} else {
-		accountsToTeams, err = teams.GetTeamsFromTeamsAPI(options.TeamsAPIBaseURL, options.TeamsAPIKey, options.CollectorRolePath)
+		// jobs are sorted by account, so this is the account's last region
+		if i == len(jobs)-1 || jobs[i+1].AccountID != job.AccountID {
+			m.AddAccountResult(job.AccountID, nil)
+		}
+	}
+
+	err = h.WriteDeduplicated()
+	if err != nil {
+		return err
+	}
+
+	s := h.Summary.Summary()
+	m.Rows = s.Rows
+	m.Findings = s.Findings
+	return writeSummary(s, summaryFileName(selector))
+}
+
+// writeSummary prints the run summary and writes it as JSON to fileName
+func writeSummary(s summary.Summary, fileName string) error {
+	if err := s.WriteText(os.Stdout); err != nil {
+		return fmt.Errorf("could not print run summary: %v", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode run summary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Clean(fileName), data, 0o600); err != nil {
+		return fmt.Errorf("could not write run summary: %v", err)
+	}
+	slog.Info("wrote run summary", "file", fileName)
+	return nil
+}
+
+// runCollection collects findings and uploads them to S3 if upload is set. In a dry run, it
+// prints the collection plan instead. Runs restricted to a subset of the team map are only
+// uploaded with --upload-subset.
+func runCollection(upload bool) error {
+	selector, err := selectorFromOptions()
+	if err != nil {
+		return err
+	}
+	if upload && selector.isSubset() && !options.UploadSubset {
+		slog.Info("not uploading the findings of a subset run to S3; use --upload-subset to upload them to the daily key", "selector", selector.String())
+		upload = false
+	}
+
+	if options.DryRun {
+		plan, err := buildCollectionPlan(upload, selector)
 		if err != nil {
-			log.Fatalf("could not load teams from Teams API: %v", err)
+			return fmt.Errorf("could not build collection plan: %v", err)
 		}
+		return plan.write(os.Stdout, options.DryRunFormat)
 	}
 
-	for account, teamName := range accountsToTeams {
-		for _, secHubRegion := range secHubRegions {
-			log.Printf("getting findings for account %v in %v", account.ID, secHubRegion)
-			err = h.GetFindingsAndWriteToOutput(secHubRegion, teamName, account)
-			if err != nil {
-				log.Fatalf("could not get findings for account %v in %v: %v", account.ID, secHubRegion, err)
-			}
+	m, err := manifest.New(time.Now(), version)
+	if err != nil {
+		return err
+	}
+	m.Options = effectiveOptions()
+	// tag every message from here on with the run ID, to find a run's messages from its manifest
+	slog.SetDefault(slog.Default().With("run_id", m.RunID))
+
+	runMetrics, stopMetrics, err := startMetrics()
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	stopTracing, err := startTracing(context.Background())
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+	ctx, span := tracing.Start(context.Background(), "collection run", tracing.AttrRunID.String(m.RunID))
+
+	err = collectFindings(ctx, options.SecurityHubRegions, selector, m, runMetrics)
+	if err != nil {
+		err = fmt.Errorf("error collecting findings: %v", err)
+	} else {
+		err = addOutputs(m, selector)
+	}
+	date := time.Now()
+	if err == nil && upload {
+		err = uploadOutputs(ctx, m, selector, date)
+	}
+
+	end := time.Now()
+	m.Finish(end, err)
+	runMetrics.RunFinished(end.Sub(m.StartTime), end, err == nil)
+	merr := writeManifest(ctx, m, manifestFileName(selector), upload && err == nil, date)
+	xerr := exportMetrics(runMetrics)
+	err = helpers.CombineErrors(err, merr, xerr)
+
+	span.SetAttributes(tracing.AttrAccounts.Int(m.Accounts.Attempted), tracing.AttrFindings.Int(m.Findings), tracing.AttrRows.Int(m.Rows))
+	tracing.End(span, err)
+	return err
+}
+
+// addOutputs records the findings, summary and redacted files in the run manifest
+func addOutputs(m *manifest.Manifest, selector jobSelector) error {
+	if err := m.AddOutput("findings", outputFileName(selector)); err != nil {
+		return err
+	}
+	if options.RedactedOutput != "" {
+		if err := m.AddOutput("redacted", options.RedactedOutput); err != nil {
+			return err
 		}
 	}
+	return m.AddOutput("summary", summaryFileName(selector))
+}
 
+// uploadOutputs uploads the findings and summary files to S3 and records their keys in the run manifest
+func uploadOutputs(ctx context.Context, m *manifest.Manifest, selector jobSelector, date time.Time) error {
+	err := writeFindingsToS3(ctx, outputFileName(selector), date)
+	if err != nil {
+		return fmt.Errorf("could not upload findings to S3: %v", err)
+	}
+	m.SetS3Key("findings", dailyS3Key(date))
+
+	err = uploadToS3(ctx, summaryFileName(selector), summaryS3Key(date), "run summary")
+	if err != nil {
+		return fmt.Errorf("could not upload run summary to S3: %v", err)
+	}
+	m.SetS3Key("summary", summaryS3Key(date))
 	return nil
 }
 
-func main() {
-	parser := flag.NewParser(&options, flag.Default)
-	_, err := parser.Parse()
+// writeManifest writes the run manifest to fileName and, if upload is set, uploads it next to the
+// findings collected on the given date
+func writeManifest(ctx context.Context, m *manifest.Manifest, fileName string, upload bool, date time.Time) error {
+	err := m.WriteFile(fileName)
 	if err != nil {
-		log.Fatalf("could not parse options: %v", err)
+		return fmt.Errorf("could not write run manifest: %v", err)
+	}
+	slog.Info("wrote run manifest", "file", fileName)
+
+	if upload {
+		err = uploadToS3(ctx, fileName, manifestS3Key(date), "run manifest")
+		if err != nil {
+			return fmt.Errorf("could not upload run manifest to S3: %v", err)
+		}
 	}
+	return nil
+}
 
-	if err := collectFindings(options.SecurityHubRegions); err != nil {
-		log.Fatalf("error collecting findings: %v", err)
+// effectiveOptions returns the value of every option, with secrets redacted
+func effectiveOptions() map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, setting := range config.Effective(parser, configFile) {
+		values[setting.Name] = setting.Value
 	}
+	return values
+}
 
-	if options.S3Bucket != "" {
-		err := writeFindingsToS3()
+// commands are the subcommands of the CLI. Running without a subcommand collects findings and
+// uploads them to S3, which is how the scheduled task runs.
+var commands = []struct {
+	name             string
+	shortDescription string
+	longDescription  string
+	data             flag.Commander
+}{
+	{
+		name:             "collect",
+		shortDescription: "Collect findings into the output file",
+		longDescription:  "Collects Security Hub findings for every account in the team map into the output file. The file is only uploaded to S3 with --upload.",
+		data:             &CollectCommand{},
+	},
+	{
+		name:             "upload",
+		shortDescription: "Upload an existing findings file to S3",
+		longDescription:  "Uploads an existing findings file to the S3 bucket under the same daily key that collection uses.",
+		data:             &UploadCommand{},
+	},
+	{
+		name:             "validate-team-map",
+		shortDescription: "Load and validate the team sources",
+		longDescription:  "Loads, merges and validates the team sources and account overrides without collecting findings, and exits with an error if they are invalid.",
+		data:             &ValidateTeamMapCommand{},
+	},
+	{
+		name:             "preflight",
+		shortDescription: "Check access to every account and the S3 bucket",
+		longDescription:  "Assumes the role for every account in the team map, calls Security Hub DescribeHub in each region, and verifies write access to the S3 bucket by overwriting a marker object at --preflight-s3-key, unless --no-preflight-s3-write is given.",
+		data:             &PreflightCommand{},
+	},
+	{
+		name:             "report",
+		shortDescription: "Summarize an existing findings file",
+		longDescription:  "Reads an existing findings file and prints counts of rows and findings by team, account, region, severity, compliance status and product.",
+		data:             &ReportCommand{},
+	},
+	{
+		name:             "convert",
+		shortDescription: "Convert a findings file to another format",
+		longDescription:  "Rewrites an existing findings file in another output format, keeping all of its columns.",
+		data:             &ConvertCommand{},
+	},
+	{
+		name:             "diff-teams",
+		shortDescription: "Compare team maps from two sources",
+		longDescription:  "Loads the team maps from two team sources and reports accounts that were added, removed, moved between teams, or changed.",
+		data:             &DiffTeamsCommand{},
+	},
+	{
+		name:             "serve",
+		shortDescription: "Serve a REST API over the latest findings",
+		longDescription:  "Loads the latest findings file, from disk or with --from-s3 from the S3 bucket, and serves a read-only REST API for listing findings and team summaries.",
+		data:             &ServeCommand{},
+	},
+	{
+		name:             "print-config",
+		shortDescription: "Print the effective configuration",
+		longDescription:  "Prints the value of every option after applying the config file, environment variables and flags, noting where each value came from. Secrets are redacted.",
+		data:             &PrintConfigCommand{},
+	},
+}
+
+// configFilePath finds the --config option before the options are parsed, since the config
+// file provides the defaults that parsing starts from
+func configFilePath() (string, error) {
+	var opts struct {
+		ConfigFile string `long:"config" env:"CONFIG_FILE"`
+	}
+	_, err := flag.NewParser(&opts, flag.IgnoreUnknown).Parse()
+	return opts.ConfigFile, err
+}
+
+func main() {
+	parser = flag.NewParser(&options, flag.Default)
+	// running without a subcommand collects findings, which is how the scheduled task runs
+	parser.SubcommandsOptional = true
+	parser.CommandHandler = func(cmd flag.Commander, args []string) error {
+		setupLogging()
+		if cmd == nil {
+			return nil
+		}
+		if err := cmd.Execute(args); err != nil {
+			fatal("command failed", err, "command", parser.Active.Name)
+		}
+		return nil
+	}
+
+	for _, c := range commands {
+		_, err := parser.AddCommand(c.name, c.shortDescription, c.longDescription, c.data)
+		if err != nil {
+			fatal("could not add command", err, "command", c.name)
+		}
+	}
+
+	configPath, err := configFilePath()
+	if err != nil {
+		fatal("could not parse options", err)
+	}
+	if configPath != "" {
+		configFile, err = config.Load(configPath)
 		if err != nil {
-			log.Fatalf("could not upload findings to S3: %v", err)
+			fatal("could not load config file", err, "file", configPath)
 		}
+		err = config.Apply(parser, configFile)
+		if err != nil {
+			fatal("invalid config file", err, "file", configPath)
+		}
+	}
+
+	_, err = parser.Parse()
+	if err != nil {
+		fatal("could not parse options", err)
+	}
+
+	// subcommands run as part of parsing
+	if parser.Active != nil {
+		return
+	}
+
+	if err := runCollection(options.S3Bucket != ""); err != nil {
+		fatal("collection failed", err)
 	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/helpers"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/metrics"
+)
+
+// startMetrics returns the metrics for a collection run, serving them on --metrics-addr if it is
+// set, and a function that stops serving them. The metrics are nil if no metrics output is
+// configured.
+func startMetrics() (*metrics.Metrics, func(), error) {
+	if options.MetricsAddr == "" && options.MetricsTextfile == "" && options.PushgatewayURL == "" {
+		return nil, func() {}, nil
+	}
+
+	runMetrics := metrics.New()
+	if options.MetricsAddr == "" {
+		return runMetrics, func() {}, nil
+	}
+
+	server, err := runMetrics.Serve(options.MetricsAddr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not serve metrics: %v", err)
+	}
+	slog.Info("serving metrics", "addr", options.MetricsAddr, "path", "/metrics")
+	return runMetrics, func() {
+		if err := server.Shutdown(context.TODO()); err != nil {
+			slog.Warn("could not stop metrics server", "error", err)
+		}
+	}, nil
+}
+
+// exportMetrics writes the metrics to --metrics-textfile and pushes them to --pushgateway-url,
+// if they are set
+func exportMetrics(runMetrics *metrics.Metrics) error {
+	if runMetrics == nil {
+		return nil
+	}
+
+	var textfileErr, pushErr error
+	if options.MetricsTextfile != "" {
+		textfileErr = runMetrics.WriteTextfile(options.MetricsTextfile)
+		if textfileErr != nil {
+			textfileErr = fmt.Errorf("could not write metrics textfile: %v", textfileErr)
+		}
+	}
+	if options.PushgatewayURL != "" {
+		pushErr = runMetrics.Push(options.PushgatewayURL)
+		if pushErr != nil {
+			pushErr = fmt.Errorf("could not push metrics: %v", pushErr)
+		}
+	}
+	return helpers.CombineErrors(textfileErr, pushErr)
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/securityhubcollector"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+)
+
+// Pagination limits for GET /findings
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Response formats for GET /findings
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// timestampLayouts are the layouts of the Created At column: the standardized layout the
+// collector writes, and RFC 3339 for files written by older versions, which passed through
+// timestamps they couldn't standardize
+var timestampLayouts = []string{securityhubcollector.TimestampLayout, time.RFC3339Nano}
+
+// Dataset is a findings file loaded for serving
+type Dataset struct {
+	// Source describes where the file was loaded from, e.g. its file name or S3 URL
+	Source   string
+	LoadedAt time.Time
+	Headers  []string
+	Rows     [][]string
+}
+
+// Server is a read-only REST API over a Dataset:
+//
+//	GET /findings                list findings, filtered and paginated, as JSON or CSV
+//	GET /teams                   list teams with their finding counts
+//	GET /teams/{team}/summary    summarize a team's findings
+//	GET /status                  describe the dataset
+type Server struct {
+	dataset Dataset
+	records []securityhubcollector.FindingRecord
+	clock   clock.Clock
+	mux     *http.ServeMux
+}
+
+// NewServer returns a Server for the dataset. The clock is used to compute finding ages.
+func NewServer(dataset Dataset, clock clock.Clock) *Server {
+	s := &Server{
+		dataset: dataset,
+		records: securityhubcollector.RecordsFromRows(dataset.Headers, dataset.Rows),
+		clock:   clock,
+		mux:     http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /findings", s.handleFindings)
+	s.mux.HandleFunc("GET /teams", s.handleTeams)
+	s.mux.HandleFunc("GET /teams/{team}/summary", s.handleTeamSummary)
+	s.mux.HandleFunc("GET /status", s.handleStatus)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// FindingsPage is the JSON response of GET /findings
+type FindingsPage struct {
+	Total    int               `json:"total"`
+	Offset   int               `json:"offset"`
+	Limit    int               `json:"limit"`
+	Findings []json.RawMessage `json:"findings"`
+}
+
+// TeamCounts is an entry in the JSON response of GET /teams
+type TeamCounts struct {
+	Team     string `json:"team"`
+	Rows     int    `json:"rows"`
+	Findings int    `json:"findings"`
+}
+
+// Status is the JSON response of GET /status
+type Status struct {
+	Source   string    `json:"source"`
+	LoadedAt time.Time `json:"loadedAt"`
+	Rows     int       `json:"rows"`
+}
+
+// filter selects findings by the query parameters of GET /findings. Every list matches any of its
+// values, and an empty list matches everything.
+type filter struct {
+	teams      []string
+	accounts   []string
+	severities []string
+	products   []string
+	controlIDs []string
+	minAgeDays int
+	maxAgeDays int
+}
+
+// parseFilter reads a filter from the team, account, severity, product, control_id, min_age_days
+// and max_age_days query parameters. Severities are matched regardless of case.
+func parseFilter(query url.Values) (filter, error) {
+	f := filter{
+		teams:      query["team"],
+		accounts:   query["account"],
+		products:   query["product"],
+		controlIDs: query["control_id"],
+		minAgeDays: -1,
+		maxAgeDays: -1,
+	}
+	for _, severity := range query["severity"] {
+		f.severities = append(f.severities, strings.ToUpper(severity))
+	}
+
+	var err error
+	if f.minAgeDays, err = intParam(query, "min_age_days", -1); err != nil {
+		return filter{}, err
+	}
+	if f.maxAgeDays, err = intParam(query, "max_age_days", -1); err != nil {
+		return filter{}, err
+	}
+	return f, nil
+}
+
+// matches returns true if the record is selected. Records whose age can't be computed don't
+// match an age filter.
+func (f filter) matches(record securityhubcollector.FindingRecord, now time.Time) bool {
+	if !matchesAny(f.teams, record.Team) ||
+		!matchesAny(f.accounts, record.AWSAccountID) ||
+		!matchesAny(f.severities, record.SeverityLabel) ||
+		!matchesAny(f.products, record.Product) ||
+		!matchesAny(f.controlIDs, record.ControlID) {
+		return false
+	}
+	if f.minAgeDays < 0 && f.maxAgeDays < 0 {
+		return true
+	}
+
+	age, ok := ageDays(record.CreatedAt, now)
+	if !ok {
+		return false
+	}
+	return (f.minAgeDays < 0 || age >= f.minAgeDays) && (f.maxAgeDays < 0 || age <= f.maxAgeDays)
+}
+
+// matchesAny returns true if there are no values or value is one of them
+func matchesAny(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
+
+// ageDays returns the number of whole days between a timestamp and now
+func ageDays(timestamp string, now time.Time) (int, bool) {
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, timestamp)
+		if err == nil {
+			return int(now.Sub(t).Hours() / 24), true
+		}
+	}
+	return 0, false
+}
+
+// intParam returns the non-negative integer query parameter name, or fallback if it isn't set
+func intParam(query url.Values, name string, fallback int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// responseFormat returns the format requested by the format query parameter or, failing that,
+// the Accept header
+func responseFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case FormatJSON, FormatCSV:
+		return format, nil
+	case "":
+		if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			return FormatCSV, nil
+		}
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("format must be %s or %s, got %q", FormatJSON, FormatCSV, format)
+	}
+}
+
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	f, err := parseFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	format, err := responseFormat(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	offset, err := intParam(query, "offset", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit, err := intParam(query, "limit", DefaultLimit)
+	if err != nil || limit == 0 || limit > MaxLimit {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", MaxLimit))
+		return
+	}
+
+	now := s.clock.Now()
+	var rows [][]string
+	for i, record := range s.records {
+		if f.matches(record, now) {
+			rows = append(rows, s.dataset.Rows[i])
+		}
+	}
+	total := len(rows)
+	// clamp before adding, so that an offset near the largest int can't overflow
+	start := min(offset, total)
+	rows = rows[start : start+min(limit, total-start)]
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if format == FormatCSV {
+		var buf bytes.Buffer
+		err = securityhubcollector.WriteRows(&buf, securityhubcollector.FormatCSV, s.dataset.Headers, rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+
+	page := FindingsPage{Total: total, Offset: offset, Limit: limit, Findings: make([]json.RawMessage, 0, len(rows))}
+	for _, row := range rows {
+		b, err := securityhubcollector.MarshalRow(s.dataset.Headers, row)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		page.Findings = append(page.Findings, b)
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleTeams(w http.ResponseWriter, _ *http.Request) {
+	builder := summary.NewBuilder()
+	for _, record := range s.records {
+		builder.Add(record.SummaryRow())
+	}
+	teams := make([]TeamCounts, 0)
+	for _, count := range builder.Summary().ByTeam {
+		teams = append(teams, TeamCounts{Team: count.Key, Rows: count.Rows, Findings: count.Findings})
+	}
+	slices.SortFunc(teams, func(a, b TeamCounts) int {
+		return strings.Compare(a.Team, b.Team)
+	})
+	writeJSON(w, http.StatusOK, teams)
+}
+
+func (s *Server) handleTeamSummary(w http.ResponseWriter, r *http.Request) {
+	team := r.PathValue("team")
+	builder := summary.NewBuilder()
+	found := false
+	for _, record := range s.records {
+		if record.Team == team {
+			builder.Add(record.SummaryRow())
+			found = true
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no findings for team %q", team))
+		return
+	}
+	writeJSON(w, http.StatusOK, builder.Summary())
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, Status{Source: s.dataset.Source, LoadedAt: s.dataset.LoadedAt, Rows: len(s.dataset.Rows)})
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
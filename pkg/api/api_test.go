@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+)
+
+var testDataset = Dataset{
+	Source:   "SecurityHub-Findings.csv",
+	LoadedAt: time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC),
+	Headers:  []string{"Team", "ID", "AWS Account ID", "Severity Label", "Product", "Control ID", "Created At"},
+	Rows: [][]string{
+		{"Team A", "f1", "000000000001", "HIGH", "Security Hub", "EC2.6", "2023-01-01T00:00:00.000Z"},
+		{"Team A", "f2", "000000000001", "LOW", "Inspector", "", "2023-01-25T00:00:00.000Z"},
+		{"Team B", "f3", "000000000002", "HIGH", "Security Hub", "S3.1", "2023-01-30T00:00:00.000Z"},
+		{"Team B", "f4", "000000000002", "CRITICAL", "GuardDuty", "", "not a timestamp"},
+	},
+}
+
+func newTestServer() *Server {
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC))
+	return NewServer(testDataset, mockClock)
+}
+
+func get(t *testing.T, s *Server, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestFindings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   string
+		total    int
+		expected []string
+	}{
+		{name: "all", target: "/findings", total: 4, expected: []string{"f1", "f2", "f3", "f4"}},
+		{name: "team", target: "/findings?team=Team+A", total: 2, expected: []string{"f1", "f2"}},
+		{name: "account", target: "/findings?account=000000000002", total: 2, expected: []string{"f3", "f4"}},
+		{name: "severities", target: "/findings?severity=high&severity=CRITICAL", total: 3, expected: []string{"f1", "f3", "f4"}},
+		{name: "product", target: "/findings?product=Inspector", total: 1, expected: []string{"f2"}},
+		{name: "control ID", target: "/findings?control_id=S3.1", total: 1, expected: []string{"f3"}},
+		{name: "minimum age", target: "/findings?min_age_days=6", total: 2, expected: []string{"f1", "f2"}},
+		{name: "maximum age", target: "/findings?max_age_days=6", total: 2, expected: []string{"f2", "f3"}},
+		{name: "combined", target: "/findings?team=Team+A&severity=HIGH&max_age_days=30", total: 1, expected: []string{"f1"}},
+		{name: "page", target: "/findings?offset=1&limit=2", total: 4, expected: []string{"f2", "f3"}},
+		{name: "past the end", target: "/findings?offset=10", total: 4, expected: []string{}},
+		{name: "largest offset", target: "/findings?offset=9223372036854775807", total: 4, expected: []string{}},
+	}
+
+	s := newTestServer()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := get(t, s, tc.target, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("ERROR: expected status 200, got %d: %s", rec.Code, rec.Body)
+			}
+			var page struct {
+				Total    int
+				Findings []map[string]string
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("ERROR: could not decode response: %s", err)
+			}
+			if page.Total != tc.total {
+				t.Errorf("ERROR: expected total %d, got %d", tc.total, page.Total)
+			}
+			actual := []string{}
+			for _, finding := range page.Findings {
+				actual = append(actual, finding["ID"])
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: findings mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindingsCSV(t *testing.T) {
+	s := newTestServer()
+	for _, rec := range []*httptest.ResponseRecorder{
+		get(t, s, "/findings?format=csv&team=Team+B", nil),
+		get(t, s, "/findings?team=Team+B", http.Header{"Accept": {"text/csv"}}),
+	} {
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+			t.Fatalf("ERROR: expected a 200 CSV response, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if total := rec.Header().Get("X-Total-Count"); total != "2" {
+			t.Errorf("ERROR: expected X-Total-Count 2, got %s", total)
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("ERROR: could not read CSV response: %s", err)
+		}
+		expected := [][]string{testDataset.Headers, testDataset.Rows[2], testDataset.Rows[3]}
+		if diff := cmp.Diff(expected, records); diff != "" {
+			t.Errorf("ERROR: CSV mismatch (-expected +actual):\n%s", diff)
+		}
+	}
+}
+
+func TestFindingsBadRequest(t *testing.T) {
+	s := newTestServer()
+	for _, target := range []string{
+		"/findings?limit=0",
+		"/findings?limit=1001",
+		"/findings?offset=-1",
+		"/findings?min_age_days=old",
+		"/findings?format=xml",
+	} {
+		if rec := get(t, s, target, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("ERROR: expected status 400 for %s, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestTeams(t *testing.T) {
+	s := newTestServer()
+	rec := get(t, s, "/teams", nil)
+	var teams []TeamCounts
+	if err := json.Unmarshal(rec.Body.Bytes(), &teams); err != nil {
+		t.Fatalf("ERROR: could not decode response: %s", err)
+	}
+	expected := []TeamCounts{{Team: "Team A", Rows: 2, Findings: 2}, {Team: "Team B", Rows: 2, Findings: 2}}
+	if diff := cmp.Diff(expected, teams); diff != "" {
+		t.Errorf("ERROR: teams mismatch (-expected +actual):\n%s", diff)
+	}
+
+	rec = get(t, s, "/teams/Team%20B/summary", nil)
+	var teamSummary summary.Summary
+	if err := json.Unmarshal(rec.Body.Bytes(), &teamSummary); err != nil {
+		t.Fatalf("ERROR: could not decode response: %s", err)
+	}
+	expectedSeverities := []summary.Count{{Key: "CRITICAL", Rows: 1, Findings: 1}, {Key: "HIGH", Rows: 1, Findings: 1}}
+	if teamSummary.Rows != 2 || !cmp.Equal(expectedSeverities, teamSummary.BySeverity) {
+		t.Errorf("ERROR: unexpected team summary: %+v", teamSummary)
+	}
+
+	if rec := get(t, s, "/teams/Team%20C/summary", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("ERROR: expected status 404 for an unknown team, got %d", rec.Code)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	rec := get(t, newTestServer(), "/status", nil)
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("ERROR: could not decode response: %s", err)
+	}
+	expected := Status{Source: testDataset.Source, LoadedAt: testDataset.LoadedAt, Rows: 4}
+	if diff := cmp.Diff(expected, status); diff != "" {
+		t.Errorf("ERROR: status mismatch (-expected +actual):\n%s", diff)
+	}
+}
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Catalog is a struct describing the format we expect in the JSON file mapping Security Hub
+// control IDs to the controls of an organization's control catalog, e.g. NIST 800-53
+type Catalog struct {
+	// Name names the catalog, e.g. NIST 800-53 Rev. 5
+	Name string `json:"name"`
+	// Controls maps Security Hub control IDs, e.g. IAM.6, to catalog controls
+	Controls map[string][]Control `json:"controls"`
+}
+
+// Control is a control in the catalog, which may belong to a family, e.g. control IA-2(1) in the
+// family IA (Identification and Authentication)
+type Control struct {
+	ID     string `json:"id"`
+	Family string `json:"family"`
+}
+
+// ParseCatalogFile reads and validates a JSON file describing a control catalog mapping
+func ParseCatalogFile(path string) (*Catalog, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading control catalog file: %s", err)
+	}
+
+	var catalog Catalog
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&catalog)
+	if err != nil {
+		return nil, fmt.Errorf("error JSON decoding control catalog: %s", err)
+	}
+
+	err = catalog.validate()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing control catalog: %w", err)
+	}
+
+	return &catalog, nil
+}
+
+// validate checks that every catalog control has an ID
+func (c *Catalog) validate() error {
+	for controlID, controls := range c.Controls {
+		if strings.TrimSpace(controlID) == "" {
+			return fmt.Errorf("control catalog maps an empty Security Hub control ID")
+		}
+		for _, control := range controls {
+			if control.ID == "" {
+				return fmt.Errorf("catalog control for %s is missing an ID", controlID)
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the IDs and the distinct families, in order, of the catalog controls that a
+// Security Hub control ID maps to
+func (c *Catalog) Lookup(controlID string) ([]string, []string) {
+	if c == nil {
+		return nil, nil
+	}
+	var ids, families []string
+	for _, control := range c.Controls[controlID] {
+		ids = append(ids, control.ID)
+		if control.Family != "" && !slices.Contains(families, control.Family) {
+			families = append(families, control.Family)
+		}
+	}
+	return ids, families
+}
@@ -0,0 +1,54 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCatalogFile(t *testing.T) {
+	catalog, err := ParseCatalogFile("control_catalog_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid control catalog file: %s", err)
+	}
+	if catalog.Name != "NIST 800-53 Rev. 5" || len(catalog.Controls) != 2 {
+		t.Errorf("ERROR: unexpected control catalog: %+v", catalog)
+	}
+
+	invalid := Catalog{Controls: map[string][]Control{"IAM.6": {{Family: "IA"}}}}
+	if err := invalid.validate(); err == nil {
+		t.Error("ERROR: didn't get expected error for catalog control without an ID")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	catalog, err := ParseCatalogFile("control_catalog_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid control catalog file: %s", err)
+	}
+
+	testCases := []struct {
+		controlID string
+		ids       []string
+		families  []string
+	}{
+		{"IAM.6", []string{"AC-2(1)", "IA-2(1)", "IA-2(2)"}, []string{"AC", "IA"}},
+		{"EC2.6", []string{"AU-12"}, nil},
+		{"S3.1", nil, nil},
+		{"", nil, nil},
+	}
+	for _, tc := range testCases {
+		ids, families := catalog.Lookup(tc.controlID)
+		if diff := cmp.Diff(tc.ids, ids); diff != "" {
+			t.Errorf("ERROR: %q catalog controls mismatch (-expected +actual):\n%s", tc.controlID, diff)
+		}
+		if diff := cmp.Diff(tc.families, families); diff != "" {
+			t.Errorf("ERROR: %q catalog families mismatch (-expected +actual):\n%s", tc.controlID, diff)
+		}
+	}
+
+	var noCatalog *Catalog
+	if ids, families := noCatalog.Lookup("IAM.6"); ids != nil || families != nil {
+		t.Error("ERROR: expected a nil catalog to map nothing")
+	}
+}
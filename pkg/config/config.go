@@ -0,0 +1,243 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of an option's effective value, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Redacted replaces the value of secret options when the configuration is printed
+const Redacted = "REDACTED"
+
+// File is a parsed config file. Its keys are the long names of the CLI options, e.g.
+// s3-bucket or sechub-regions. A key named after a subcommand may hold a nested map of
+// that subcommand's options.
+type File map[string]interface{}
+
+// Setting is the effective value of a single option and where it came from
+type Setting struct {
+	Name   string
+	Value  interface{}
+	Source string
+}
+
+// Load reads a YAML config file. Since YAML is a superset of JSON, JSON files work too.
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses the contents of a YAML config file
+func Parse(data []byte) (File, error) {
+	file := File{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse config file: %v", err)
+	}
+	return file, nil
+}
+
+// Apply makes the values in the file the defaults of the matching parser options. It must be
+// called before the parser runs, which then lets environment variables and flags override
+// the file, giving the precedence flags > env > file > defaults.
+func Apply(parser *flags.Parser, file File) error {
+	return apply(parser.Command, file, "")
+}
+
+// apply sets option defaults on cmd from values, reporting unknown keys relative to prefix
+func apply(cmd *flags.Command, values map[string]interface{}, prefix string) error {
+	for key, value := range values {
+		// a map names a subcommand, since options and subcommands can share a name
+		if section, ok := asMap(value); ok {
+			sub := cmd.Find(key)
+			if sub == nil {
+				return fmt.Errorf("%s%s: unknown subcommand", prefix, key)
+			}
+			if err := apply(sub, section, prefix+key+"."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		opt := findOption(cmd, key)
+		if opt == nil {
+			return fmt.Errorf("%s%s: unknown option", prefix, key)
+		}
+		defaults, err := optionValues(opt, value)
+		if err != nil {
+			return fmt.Errorf("%s%s: %v", prefix, key, err)
+		}
+		opt.Default = defaults
+	}
+	return nil
+}
+
+// asMap returns value as a map if it is one. Nested maps decode to the type of the outer map,
+// so they are Files when decoding a File.
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case File:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	}
+	return nil, false
+}
+
+// findOption finds an option defined by cmd itself, not by its parent commands
+func findOption(cmd *flags.Command, longName string) *flags.Option {
+	for _, opt := range options(cmd.Group) {
+		if opt.LongNameWithNamespace() == longName {
+			return opt
+		}
+	}
+	return nil
+}
+
+// options returns the options of group and its subgroups
+func options(group *flags.Group) []*flags.Option {
+	opts := group.Options()
+	for _, g := range group.Groups() {
+		opts = append(opts, options(g)...)
+	}
+	return opts
+}
+
+// optionValues converts a config file value to the string values go-flags parses for opt.
+// Lists are only accepted for options that can be repeated.
+func optionValues(opt *flags.Option, value interface{}) ([]string, error) {
+	var values []string
+	switch v := value.(type) {
+	case []interface{}:
+		if opt.Field().Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("expected a single value, got a list")
+		}
+		for _, item := range v {
+			s, err := scalar(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+	default:
+		s, err := scalar(v)
+		if err != nil {
+			return nil, err
+		}
+		values = []string{s}
+	}
+
+	if len(opt.Choices) > 0 {
+		for _, v := range values {
+			if !contains(opt.Choices, v) {
+				return nil, fmt.Errorf("invalid value %q; allowed values are %s", v, strings.Join(opt.Choices, ", "))
+			}
+		}
+	}
+	return values, nil
+}
+
+// scalar formats a single config file value
+func scalar(value interface{}) (string, error) {
+	if _, ok := asMap(value); ok || value == nil {
+		return "", fmt.Errorf("expected a string, number or boolean")
+	}
+	if _, ok := value.([]interface{}); ok {
+		return "", fmt.Errorf("expected a string, number or boolean")
+	}
+	return fmt.Sprint(value), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Effective returns the effective value of every top-level option of a parsed parser, and
+// where it came from. Options tagged secret:"true" are redacted when they have a value.
+func Effective(parser *flags.Parser, file File) []Setting {
+	var settings []Setting
+	for _, opt := range options(parser.Command.Group) {
+		// skip options without a long name, and callbacks like --help
+		name := opt.LongNameWithNamespace()
+		if name == "" || opt.Field().Type.Kind() == reflect.Func {
+			continue
+		}
+
+		value := opt.Value()
+		if opt.Field().Tag.Get("secret") == "true" && !reflect.ValueOf(value).IsZero() {
+			value = Redacted
+		}
+
+		settings = append(settings, Setting{Name: name, Value: value, Source: valueSource(opt, file)})
+	}
+	return settings
+}
+
+// valueSource returns where the value of a parsed option came from
+func valueSource(opt *flags.Option, file File) string {
+	// go-flags also marks options as set when it applies an env var or default, but then it
+	// marks them as set from a default too
+	if opt.IsSet() && !opt.IsSetDefault() {
+		return SourceFlag
+	}
+	if key := opt.EnvKeyWithNamespace(); key != "" {
+		if _, ok := os.LookupEnv(key); ok {
+			return SourceEnv
+		}
+	}
+	if value, ok := file[opt.LongNameWithNamespace()]; ok {
+		// a map is a subcommand section with the same name as the option
+		if _, isMap := asMap(value); !isMap {
+			return SourceFile
+		}
+	}
+	return SourceDefault
+}
+
+// WriteYAML writes the settings as a YAML config file, noting the source of each value in a
+// comment. The output can be used as a config file.
+func WriteYAML(w io.Writer, settings []Setting) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings {
+		var value yaml.Node
+		if err := value.Encode(s.Value); err != nil {
+			return fmt.Errorf("could not encode %s: %v", s.Name, err)
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: s.Name}
+		// comments on lists are only written next to their key
+		if value.Kind == yaml.ScalarNode {
+			value.LineComment = s.Source
+		} else {
+			key.LineComment = s.Source
+		}
+		doc.Content = append(doc.Content, key, &value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
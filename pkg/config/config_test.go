@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	flags "github.com/jessevdk/go-flags"
+)
+
+type testOptions struct {
+	Bucket  string   `long:"s3-bucket" env:"CONFIG_TEST_S3_BUCKET" default:"default-bucket"`
+	Key     string   `long:"s3-key" env:"CONFIG_TEST_S3_KEY"`
+	Regions []string `long:"sechub-regions" env:"CONFIG_TEST_SECHUB_REGIONS" env-delim:"," default:"us-east-1" default:"us-west-2"`
+	Format  string   `long:"output-format" choice:"tsv" choice:"csv" default:"tsv"`
+	APIKey  string   `long:"teams-api-key" secret:"true"`
+	DryRun  bool     `long:"dry-run"`
+}
+
+type testCommand struct {
+	Concurrency int `long:"concurrency" default:"10"`
+}
+
+func (c *testCommand) Execute(_ []string) error {
+	return nil
+}
+
+func newTestParser(t *testing.T, opts *testOptions, cmd *testCommand) *flags.Parser {
+	parser := flags.NewParser(opts, flags.Default)
+	parser.SubcommandsOptional = true
+	if _, err := parser.AddCommand("preflight", "", "", cmd); err != nil {
+		t.Fatalf("ERROR: could not add command: %s", err)
+	}
+	return parser
+}
+
+func TestApplyPrecedence(t *testing.T) {
+	t.Setenv("CONFIG_TEST_S3_KEY", "env-key")
+
+	file, err := Parse([]byte(`
+s3-bucket: file-bucket
+s3-key: file-key
+sechub-regions: [us-east-2]
+output-format: csv
+dry-run: true
+preflight:
+  concurrency: 3
+`))
+	if err != nil {
+		t.Fatalf("ERROR: could not parse config: %s", err)
+	}
+
+	var opts testOptions
+	var cmd testCommand
+	parser := newTestParser(t, &opts, &cmd)
+	if err := Apply(parser, file); err != nil {
+		t.Fatalf("ERROR: could not apply config: %s", err)
+	}
+	if _, err := parser.ParseArgs([]string{"--output-format", "tsv", "preflight"}); err != nil {
+		t.Fatalf("ERROR: could not parse args: %s", err)
+	}
+
+	expected := testOptions{
+		Bucket:  "file-bucket",
+		Key:     "env-key",
+		Regions: []string{"us-east-2"},
+		Format:  "tsv",
+		DryRun:  true,
+	}
+	if diff := cmp.Diff(expected, opts); diff != "" {
+		t.Errorf("ERROR: options mismatch (-expected +actual):\n%s", diff)
+	}
+	if cmd.Concurrency != 3 {
+		t.Errorf("ERROR: expected concurrency 3 from the config file, got %d", cmd.Concurrency)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+	}{
+		{name: "unknown option", config: "s3-buckt: bucket"},
+		{name: "invalid choice", config: "output-format: xml"},
+		{name: "list for single value", config: "s3-bucket: [a, b]"},
+		{name: "map for option", config: "s3-bucket: {name: bucket}"},
+		{name: "unknown subcommand option", config: "preflight: {format: json}"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := Parse([]byte(tc.config))
+			if err != nil {
+				t.Fatalf("ERROR: could not parse config: %s", err)
+			}
+			var opts testOptions
+			if err := Apply(newTestParser(t, &opts, &testCommand{}), file); err == nil {
+				t.Errorf("ERROR: expected an error applying %q", tc.config)
+			}
+		})
+	}
+}
+
+func TestEffective(t *testing.T) {
+	t.Setenv("CONFIG_TEST_SECHUB_REGIONS", "us-east-1,eu-west-1")
+
+	file, err := Parse([]byte("teams-api-key: secret\ns3-key: file-key\n"))
+	if err != nil {
+		t.Fatalf("ERROR: could not parse config: %s", err)
+	}
+
+	var opts testOptions
+	parser := newTestParser(t, &opts, &testCommand{})
+	if err := Apply(parser, file); err != nil {
+		t.Fatalf("ERROR: could not apply config: %s", err)
+	}
+	if _, err := parser.ParseArgs([]string{"--dry-run"}); err != nil {
+		t.Fatalf("ERROR: could not parse args: %s", err)
+	}
+
+	expected := []Setting{
+		{Name: "s3-bucket", Value: "default-bucket", Source: SourceDefault},
+		{Name: "s3-key", Value: "file-key", Source: SourceFile},
+		{Name: "sechub-regions", Value: []string{"us-east-1", "eu-west-1"}, Source: SourceEnv},
+		{Name: "output-format", Value: "tsv", Source: SourceDefault},
+		{Name: "teams-api-key", Value: Redacted, Source: SourceFile},
+		{Name: "dry-run", Value: true, Source: SourceFlag},
+	}
+	actual := Effective(parser, file)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("ERROR: settings mismatch (-expected +actual):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteYAML(&buf, actual); err != nil {
+		t.Fatalf("ERROR: could not write settings: %s", err)
+	}
+	expectedYAML := `s3-bucket: default-bucket # default
+s3-key: file-key # file
+sechub-regions: # env
+  - us-east-1
+  - eu-west-1
+output-format: tsv # default
+teams-api-key: REDACTED # file
+dry-run: true # flag
+`
+	if diff := cmp.Diff(expectedYAML, buf.String()); diff != "" {
+		t.Errorf("ERROR: YAML mismatch (-expected +actual):\n%s", diff)
+	}
+}
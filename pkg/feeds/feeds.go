@@ -0,0 +1,122 @@
+package feeds
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EPSS maps CVE IDs to their EPSS score, the probability that they will be exploited in the next
+// 30 days, from a FIRST EPSS feed
+type EPSS map[string]float64
+
+// KEV is the set of CVE IDs in the CISA Known Exploited Vulnerabilities catalog
+type KEV map[string]bool
+
+// LoadEPSS reads an EPSS feed, as the CSV published by FIRST at
+// https://epss.cyentia.com/epss_scores-current.csv.gz. Gzipped files are decompressed.
+func LoadEPSS(path string) (EPSS, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading EPSS feed: %s", err)
+	}
+	defer f.Close() //nolint
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing EPSS feed: %s", err)
+		}
+		defer gz.Close() //nolint
+		r = gz
+	}
+
+	reader := csv.NewReader(r)
+	// the feed starts with a #model_version:...,score_date:... comment line
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading EPSS feed header: %s", err)
+	}
+	cveColumn, scoreColumn := indexOf(header, "cve"), indexOf(header, "epss")
+	if cveColumn < 0 || scoreColumn < 0 {
+		return nil, fmt.Errorf("EPSS feed is missing a cve or epss column")
+	}
+
+	epss := EPSS{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading EPSS feed: %s", err)
+		}
+		if len(record) <= max(cveColumn, scoreColumn) {
+			continue
+		}
+		score, err := strconv.ParseFloat(record[scoreColumn], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EPSS score for %s: %q", record[cveColumn], record[scoreColumn])
+		}
+		epss[strings.ToUpper(record[cveColumn])] = score
+	}
+	return epss, nil
+}
+
+// Score returns the EPSS score of a CVE ID, if the feed has one
+func (e EPSS) Score(cveID string) (float64, bool) {
+	score, ok := e[strings.ToUpper(cveID)]
+	return score, ok
+}
+
+// LoadKEV reads the CISA Known Exploited Vulnerabilities catalog, as the JSON published at
+// https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json
+func LoadKEV(path string) (KEV, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading KEV catalog: %s", err)
+	}
+
+	var catalog struct {
+		Vulnerabilities []struct {
+			CVEID string `json:"cveID"`
+		} `json:"vulnerabilities"`
+	}
+	err = json.Unmarshal(b, &catalog)
+	if err != nil {
+		return nil, fmt.Errorf("error JSON decoding KEV catalog: %s", err)
+	}
+
+	kev := KEV{}
+	for _, vulnerability := range catalog.Vulnerabilities {
+		if vulnerability.CVEID != "" {
+			kev[strings.ToUpper(vulnerability.CVEID)] = true
+		}
+	}
+	return kev, nil
+}
+
+// Contains returns true if a CVE ID is in the catalog
+func (k KEV) Contains(cveID string) bool {
+	return k[strings.ToUpper(cveID)]
+}
+
+// indexOf returns the index of a column in a header row, ignoring case, or -1
+func indexOf(header []string, column string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), column) {
+			return i
+		}
+	}
+	return -1
+}
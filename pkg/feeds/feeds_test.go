@@ -0,0 +1,62 @@
+package feeds
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gzipFile writes a gzipped copy of a file to dir and returns its name
+func gzipFile(t *testing.T, fileName, dir string) string {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("ERROR: could not read %s: %s", fileName, err)
+	}
+	gzFileName := filepath.Join(dir, fileName+".gz")
+	f, err := os.Create(gzFileName)
+	if err != nil {
+		t.Fatalf("ERROR: could not create %s: %s", gzFileName, err)
+	}
+	defer f.Close() //nolint
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write(b); err != nil {
+		t.Fatalf("ERROR: could not write %s: %s", gzFileName, err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("ERROR: could not close %s: %s", gzFileName, err)
+	}
+	return gzFileName
+}
+
+func TestLoadEPSS(t *testing.T) {
+	for _, fileName := range []string{"epss_test.csv", gzipFile(t, "epss_test.csv", t.TempDir())} {
+		epss, err := LoadEPSS(fileName)
+		if err != nil {
+			t.Fatalf("ERROR: could not load %s: %s", fileName, err)
+		}
+		if len(epss) != 2 {
+			t.Errorf("ERROR: expected 2 scores in %s, got %d", fileName, len(epss))
+		}
+		if score, ok := epss.Score("cve-2021-44228"); !ok || score != 0.97565 {
+			t.Errorf("ERROR: expected score 0.97565 for CVE-2021-44228 in %s, got %v, %t", fileName, score, ok)
+		}
+		if _, ok := epss.Score("CVE-2023-34362"); ok {
+			t.Errorf("ERROR: expected no score for CVE-2023-34362 in %s", fileName)
+		}
+	}
+
+	if _, err := LoadEPSS("kev_test.json"); err == nil {
+		t.Error("ERROR: expected an error loading a file without cve and epss columns")
+	}
+}
+
+func TestLoadKEV(t *testing.T) {
+	kev, err := LoadKEV("kev_test.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not load KEV catalog: %s", err)
+	}
+	if !kev.Contains("CVE-2021-44228") || !kev.Contains("cve-2023-34362") || kev.Contains("CVE-2022-0001") {
+		t.Errorf("ERROR: unexpected KEV catalog: %v", kev)
+	}
+}
@@ -0,0 +1,193 @@
+package manifest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"time"
+)
+
+// Run statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Manifest describes a collection run and the files it produced, so that consumers of the output
+// can tell whether a file is complete and how it was produced
+type Manifest struct {
+	RunID     string                 `json:"runId"`
+	StartTime time.Time              `json:"startTime"`
+	EndTime   time.Time              `json:"endTime"`
+	Status    string                 `json:"status"`
+	Error     string                 `json:"error,omitempty"`
+	Collector Collector              `json:"collector"`
+	Options   map[string]interface{} `json:"options"`
+
+	TeamSources []string `json:"teamSources"`
+	TeamMapHash string   `json:"teamMapHash"`
+
+	Accounts Accounts `json:"accounts"`
+	Rows     int      `json:"rows"`
+	Findings int      `json:"findings"`
+	Outputs  []Output `json:"outputs"`
+}
+
+// Collector identifies the build of the collector that produced a run
+type Collector struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// Accounts counts the accounts in a run. Attempted accounts are those the run started collecting
+// from, which is fewer than Planned when the run stops early. A run stops at the first account it
+// cannot collect from, so Failed holds at most one account.
+type Accounts struct {
+	Planned   int      `json:"planned"`
+	Attempted int      `json:"attempted"`
+	Succeeded int      `json:"succeeded"`
+	Failed    []string `json:"failed"`
+}
+
+// Output is a file written by a run
+type Output struct {
+	Kind   string `json:"kind"`
+	File   string `json:"file"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+	S3Key  string `json:"s3Key,omitempty"`
+}
+
+// New returns a manifest for a run starting at start, with a new run ID
+func New(start time.Time, version string) (*Manifest, error) {
+	runID, err := NewRunID()
+	if err != nil {
+		return nil, err
+	}
+	return &Manifest{
+		RunID:     runID,
+		StartTime: start.UTC(),
+		Status:    StatusFailed,
+		Collector: CollectorInfo(version),
+		Accounts:  Accounts{Failed: []string{}},
+		Outputs:   []Output{},
+	}, nil
+}
+
+// NewRunID returns a random version 4 UUID
+func NewRunID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("could not generate run ID: %v", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// CollectorInfo returns the collector version along with the VCS commit the binary was built
+// from, if the Go toolchain recorded one
+func CollectorInfo(version string) Collector {
+	c := Collector{Version: version}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return c
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			c.Commit = setting.Value
+		case "vcs.modified":
+			c.Modified = setting.Value == "true"
+		}
+	}
+	return c
+}
+
+// AddAccountResult records whether collection from an account succeeded
+func (m *Manifest) AddAccountResult(accountID string, err error) {
+	m.Accounts.Attempted++
+	if err != nil {
+		m.Accounts.Failed = append(m.Accounts.Failed, accountID)
+		slices.Sort(m.Accounts.Failed)
+		return
+	}
+	m.Accounts.Succeeded++
+}
+
+// AddOutput records a file written by the run along with its size and checksum
+func (m *Manifest) AddOutput(kind, fileName string) error {
+	size, checksum, err := FileChecksum(fileName)
+	if err != nil {
+		return err
+	}
+	m.Outputs = append(m.Outputs, Output{Kind: kind, File: fileName, Bytes: size, SHA256: checksum})
+	return nil
+}
+
+// SetS3Key records the S3 key an output was uploaded to
+func (m *Manifest) SetS3Key(kind, key string) {
+	for i := range m.Outputs {
+		if m.Outputs[i].Kind == kind {
+			m.Outputs[i].S3Key = key
+		}
+	}
+}
+
+// Finish records the end of the run and its outcome
+func (m *Manifest) Finish(end time.Time, err error) {
+	m.EndTime = end.UTC()
+	if err != nil {
+		m.Status = StatusFailed
+		m.Error = err.Error()
+		return
+	}
+	m.Status = StatusSucceeded
+	m.Error = ""
+}
+
+// Write writes the manifest as indented JSON
+func (m *Manifest) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+// WriteFile writes the manifest to a file
+func (m *Manifest) WriteFile(fileName string) (err error) {
+	f, err := os.Create(filepath.Clean(fileName))
+	if err != nil {
+		return fmt.Errorf("could not create manifest file: %v", err)
+	}
+	defer func() {
+		cerr := f.Close()
+		if err == nil && cerr != nil {
+			err = fmt.Errorf("could not close manifest file: %v", cerr)
+		}
+	}()
+	return m.Write(f)
+}
+
+// FileChecksum returns the size and hex SHA-256 checksum of a file
+func FileChecksum(fileName string) (int64, string, error) {
+	f, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return 0, "", fmt.Errorf("could not open %s: %v", fileName, err)
+	}
+	defer f.Close() //nolint
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("could not read %s: %v", fileName, err)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewRunID(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first, err := NewRunID()
+	if err != nil {
+		t.Fatalf("ERROR: could not generate run ID: %s", err)
+	}
+	second, err := NewRunID()
+	if err != nil {
+		t.Fatalf("ERROR: could not generate run ID: %s", err)
+	}
+	if !uuidPattern.MatchString(first) {
+		t.Errorf("ERROR: expected a version 4 UUID, got %q", first)
+	}
+	if first == second {
+		t.Errorf("ERROR: expected different run IDs, got %q twice", first)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, err := New(start, "1.2.3")
+	if err != nil {
+		t.Fatalf("ERROR: could not create manifest: %s", err)
+	}
+	if m.Status != StatusFailed {
+		t.Errorf("ERROR: expected a new manifest to be %s until the run finishes, got %s", StatusFailed, m.Status)
+	}
+
+	m.AddAccountResult("000000000002", nil)
+	m.AddAccountResult("000000000001", fmt.Errorf("access denied"))
+	expectedAccounts := Accounts{Attempted: 2, Succeeded: 1, Failed: []string{"000000000001"}}
+	if diff := cmp.Diff(expectedAccounts, m.Accounts); diff != "" {
+		t.Errorf("ERROR: accounts mismatch (-expected +actual):\n%s", diff)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "findings.csv")
+	if err := os.WriteFile(fileName, []byte("hello\n"), 0o600); err != nil {
+		t.Fatalf("ERROR: could not write test file: %s", err)
+	}
+	if err := m.AddOutput("findings", fileName); err != nil {
+		t.Fatalf("ERROR: could not add output: %s", err)
+	}
+	m.SetS3Key("findings", "SecurityHub-Findings_01-01-2023.csv")
+	expectedOutputs := []Output{{
+		Kind:   "findings",
+		File:   fileName,
+		Bytes:  6,
+		SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		S3Key:  "SecurityHub-Findings_01-01-2023.csv",
+	}}
+	if diff := cmp.Diff(expectedOutputs, m.Outputs); diff != "" {
+		t.Errorf("ERROR: outputs mismatch (-expected +actual):\n%s", diff)
+	}
+
+	m.Finish(start.Add(time.Minute), nil)
+	if m.Status != StatusSucceeded || m.Error != "" {
+		t.Errorf("ERROR: expected a succeeded run, got status %s and error %q", m.Status, m.Error)
+	}
+
+	var b bytes.Buffer
+	if err := m.Write(&b); err != nil {
+		t.Fatalf("ERROR: could not write manifest: %s", err)
+	}
+	var decoded Manifest
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatalf("ERROR: could not decode manifest: %s", err)
+	}
+	if diff := cmp.Diff(*m, decoded); diff != "" {
+		t.Errorf("ERROR: decoded manifest mismatch (-expected +actual):\n%s", diff)
+	}
+}
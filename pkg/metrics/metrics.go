@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+)
+
+// JobName is the Pushgateway job the collector's metrics are pushed under
+const JobName = "security_hub_collector"
+
+const namespace = "security_hub_collector"
+
+// Metrics holds the Prometheus metrics for a collection run. A nil *Metrics is valid and
+// records nothing, so callers don't need to check whether metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	findingsCollected *prometheus.CounterVec
+	apiDuration       *prometheus.HistogramVec
+	apiErrors         *prometheus.CounterVec
+	apiThrottles      *prometheus.CounterVec
+	accountsFailed    prometheus.Counter
+	runDuration       prometheus.Gauge
+	lastSuccess       prometheus.Gauge
+	succeeded         bool
+}
+
+// New returns Metrics registered in a new registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		findingsCollected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "findings_collected_total",
+			Help:      "Finding rows written to the output, by team and severity label.",
+		}, []string{"team", "severity"}),
+		apiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Duration of AWS API request attempts, by service, operation and region.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+		}, []string{"service", "operation", "region"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_errors_total",
+			Help:      "Failed AWS API request attempts, by service, operation, region and error code.",
+		}, []string{"service", "operation", "region", "code"}),
+		apiThrottles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_throttles_total",
+			Help:      "AWS API request attempts that were throttled, by service, operation and region.",
+		}, []string{"service", "operation", "region"}),
+		accountsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "accounts_failed_total",
+			Help:      "Accounts that findings could not be collected from.",
+		}),
+		runDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "run_duration_seconds",
+			Help:      "Duration of the collection run.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time at which the last successful collection run finished.",
+		}),
+	}
+	m.registry.MustRegister(m.findingsCollected, m.apiDuration, m.apiErrors, m.apiThrottles, m.accountsFailed, m.runDuration)
+	return m
+}
+
+// Gatherer returns the registry holding the metrics
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
+
+// FindingCollected counts a finding row written to the output
+func (m *Metrics) FindingCollected(team, severity string) {
+	if m == nil {
+		return
+	}
+	m.findingsCollected.WithLabelValues(team, severity).Inc()
+}
+
+// AccountFailed counts an account that findings could not be collected from
+func (m *Metrics) AccountFailed() {
+	if m == nil {
+		return
+	}
+	m.accountsFailed.Inc()
+}
+
+// RunFinished records the duration of the run and, if it succeeded, the time it finished. The
+// last success timestamp is only registered once a run succeeds, so that pushing the metrics of
+// a failed run doesn't overwrite the timestamp of the last successful one.
+func (m *Metrics) RunFinished(duration time.Duration, end time.Time, succeeded bool) {
+	if m == nil {
+		return
+	}
+	m.runDuration.Set(duration.Seconds())
+	if succeeded {
+		m.succeeded = true
+		m.setLastSuccess(float64(end.Unix()))
+	}
+}
+
+// setLastSuccess sets and registers the last success timestamp
+func (m *Metrics) setLastSuccess(timestamp float64) {
+	m.lastSuccess.Set(timestamp)
+	// registering twice is harmless, since the gauge is the same
+	_ = m.registry.Register(m.lastSuccess)
+}
+
+// APIOptions returns AWS SDK middleware that records the duration, errors and throttling of
+// every API request attempt. Pass it to config.WithAPIOptions.
+func (m *Metrics) APIOptions() []func(*middleware.Stack) error {
+	if m == nil {
+		return nil
+	}
+	return []func(*middleware.Stack) error{
+		func(stack *middleware.Stack) error {
+			// added at the end of the finalize step, after the retry middleware, so that every
+			// attempt is observed
+			return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CollectorMetrics", m.observeAttempt), middleware.After)
+		},
+	}
+}
+
+func (m *Metrics) observeAttempt(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	start := time.Now()
+	out, metadata, err := next.HandleFinalize(ctx, in)
+
+	service := awsmiddleware.GetServiceID(ctx)
+	operation := awsmiddleware.GetOperationName(ctx)
+	region := awsmiddleware.GetRegion(ctx)
+	m.apiDuration.WithLabelValues(service, operation, region).Observe(time.Since(start).Seconds())
+	if err != nil {
+		code := client.ErrorClass(err)
+		m.apiErrors.WithLabelValues(service, operation, region, code).Inc()
+		if _, ok := retry.DefaultThrottleErrorCodes[code]; ok {
+			m.apiThrottles.WithLabelValues(service, operation, region).Inc()
+		}
+	}
+	return out, metadata, err
+}
+
+// Serve serves the metrics at /metrics on addr in the background until the returned server is
+// shut down
+func (m *Metrics) Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	return server, nil
+}
+
+// WriteTextfile writes the metrics in the text format read by the node exporter's textfile
+// collector. The file is replaced, so unless the run succeeded, the last success timestamp is
+// carried over from the file, keeping it stale rather than absent after a failed run.
+func (m *Metrics) WriteTextfile(fileName string) error {
+	if !m.succeeded {
+		if timestamp, ok := textfileLastSuccess(fileName); ok {
+			m.setLastSuccess(timestamp)
+		}
+	}
+	return prometheus.WriteToTextfile(fileName, m.registry)
+}
+
+// textfileLastSuccess returns the last success timestamp in a textfile written by an earlier run,
+// if there is one
+func textfileLastSuccess(fileName string) (float64, bool) {
+	f, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close() //nolint
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(f)
+	if err != nil {
+		return 0, false
+	}
+	family := families[namespace+"_last_success_timestamp_seconds"]
+	if family == nil || len(family.GetMetric()) == 0 {
+		return 0, false
+	}
+	return family.GetMetric()[0].GetGauge().GetValue(), true
+}
+
+// Push adds the metrics to a Pushgateway under JobName. Metrics from earlier pushes that this run
+// doesn't have, like the last success timestamp after a failed run, are kept.
+func (m *Metrics) Push(url string) error {
+	return push.New(url, JobName).Gatherer(m.registry).Add()
+}
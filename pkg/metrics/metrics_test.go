@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gather returns the metric families in m by name
+func gather(t *testing.T, m *Metrics) map[string]*dto.MetricFamily {
+	families, err := m.Gatherer().Gather()
+	if err != nil {
+		t.Fatalf("ERROR: could not gather metrics: %s", err)
+	}
+	byName := make(map[string]*dto.MetricFamily)
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+// labels returns the labels of a metric as a map
+func labels(metric *dto.Metric) map[string]string {
+	l := make(map[string]string)
+	for _, pair := range metric.GetLabel() {
+		l[pair.GetName()] = pair.GetValue()
+	}
+	return l
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	// none of these should panic
+	m.FindingCollected("Test Team", "HIGH")
+	m.AccountFailed()
+	m.RunFinished(time.Minute, time.Now(), true)
+	if options := m.APIOptions(); options != nil {
+		t.Errorf("ERROR: expected no API options for nil metrics, got %d", len(options))
+	}
+}
+
+func TestRunMetrics(t *testing.T) {
+	m := New()
+	m.FindingCollected("Test Team", "HIGH")
+	m.FindingCollected("Test Team", "HIGH")
+	m.FindingCollected("Test Team", "LOW")
+	m.AccountFailed()
+
+	end := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.RunFinished(time.Minute, end, false)
+	families := gather(t, m)
+	if _, ok := families["security_hub_collector_last_success_timestamp_seconds"]; ok {
+		t.Errorf("ERROR: expected no last success timestamp after a failed run")
+	}
+	if actual := families["security_hub_collector_run_duration_seconds"].GetMetric()[0].GetGauge().GetValue(); actual != 60 {
+		t.Errorf("ERROR: expected a run duration of 60 seconds, got %v", actual)
+	}
+	if actual := families["security_hub_collector_accounts_failed_total"].GetMetric()[0].GetCounter().GetValue(); actual != 1 {
+		t.Errorf("ERROR: expected 1 failed account, got %v", actual)
+	}
+
+	findings := map[string]float64{}
+	for _, metric := range families["security_hub_collector_findings_collected_total"].GetMetric() {
+		l := labels(metric)
+		findings[l["team"]+"/"+l["severity"]] = metric.GetCounter().GetValue()
+	}
+	if findings["Test Team/HIGH"] != 2 || findings["Test Team/LOW"] != 1 {
+		t.Errorf("ERROR: unexpected findings collected: %v", findings)
+	}
+
+	m.RunFinished(time.Minute, end, true)
+	families = gather(t, m)
+	if actual := families["security_hub_collector_last_success_timestamp_seconds"].GetMetric()[0].GetGauge().GetValue(); actual != float64(end.Unix()) {
+		t.Errorf("ERROR: expected a last success timestamp of %d, got %v", end.Unix(), actual)
+	}
+}
+
+func TestAPIOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-ErrorType", "ThrottlingException")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"Rate exceeded"}`))
+	}))
+	defer server.Close()
+
+	m := New()
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion("us-east-1"),
+		config.WithBaseEndpoint(server.URL),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")),
+		config.WithRetryMaxAttempts(1),
+		config.WithAPIOptions(m.APIOptions()),
+	)
+	if err != nil {
+		t.Fatalf("ERROR: could not load SDK config: %s", err)
+	}
+	_, err = securityhub.NewFromConfig(cfg).GetFindings(context.TODO(), &securityhub.GetFindingsInput{MaxResults: aws.Int32(1)})
+	if err == nil {
+		t.Fatal("ERROR: expected GetFindings to fail")
+	}
+
+	families := gather(t, m)
+	expectedLabels := map[string]string{"service": "SecurityHub", "operation": "GetFindings", "region": "us-east-1"}
+	duration := families["security_hub_collector_api_request_duration_seconds"].GetMetric()
+	if len(duration) != 1 || duration[0].GetHistogram().GetSampleCount() != 1 {
+		t.Fatalf("ERROR: expected a single API request duration observation, got %v", duration)
+	}
+	for name, value := range expectedLabels {
+		if actual := labels(duration[0])[name]; actual != value {
+			t.Errorf("ERROR: expected API request duration label %s=%s, got %s", name, value, actual)
+		}
+	}
+
+	apiErrors := families["security_hub_collector_api_errors_total"].GetMetric()
+	if len(apiErrors) != 1 || labels(apiErrors[0])["code"] != "ThrottlingException" {
+		t.Errorf("ERROR: expected a ThrottlingException API error, got %v", apiErrors)
+	}
+	throttles := families["security_hub_collector_api_throttles_total"].GetMetric()
+	if len(throttles) != 1 || throttles[0].GetCounter().GetValue() != 1 {
+		t.Errorf("ERROR: expected one throttle, got %v", throttles)
+	}
+}
+
+func TestExport(t *testing.T) {
+	m := New()
+	m.FindingCollected("Test Team", "HIGH")
+	m.RunFinished(time.Minute, time.Now(), true)
+
+	fileName := filepath.Join(t.TempDir(), "security_hub_collector.prom")
+	if err := m.WriteTextfile(fileName); err != nil {
+		t.Fatalf("ERROR: could not write textfile: %s", err)
+	}
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("ERROR: could not read textfile: %s", err)
+	}
+	if !strings.Contains(string(b), `security_hub_collector_findings_collected_total{severity="HIGH",team="Test Team"} 1`) {
+		t.Errorf("ERROR: expected findings collected in textfile:\n%s", b)
+	}
+
+	// a failed run keeps the last success timestamp of the previous run in the textfile
+	failed := New()
+	failed.RunFinished(time.Minute, time.Now(), false)
+	if err := failed.WriteTextfile(fileName); err != nil {
+		t.Fatalf("ERROR: could not write textfile: %s", err)
+	}
+	previous, ok := textfileLastSuccess(fileName)
+	if !ok {
+		t.Fatalf("ERROR: expected the last success timestamp to be kept after a failed run")
+	}
+	if expected := gather(t, m)[namespace+"_last_success_timestamp_seconds"].GetMetric()[0].GetGauge().GetValue(); previous != expected {
+		t.Errorf("ERROR: expected last success timestamp %v after a failed run, got %v", expected, previous)
+	}
+
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	if err := m.Push(server.URL); err != nil {
+		t.Fatalf("ERROR: could not push metrics: %s", err)
+	}
+	if method != http.MethodPost || path != "/metrics/job/"+JobName {
+		t.Errorf("ERROR: expected POST /metrics/job/%s, got %s %s", JobName, method, path)
+	}
+}
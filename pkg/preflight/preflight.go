@@ -0,0 +1,232 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	"github.com/aws/smithy-go"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+// Names of the checks that preflight runs
+const (
+	CheckAssumeRole  = "assume-role"
+	CheckDescribeHub = "describe-hub"
+	CheckS3Write     = "s3-write"
+)
+
+// DefaultConcurrency is the number of accounts checked at once when Concurrency is not set
+const DefaultConcurrency = 10
+
+// DefaultS3Key is the key of the marker object the S3 write check writes by default
+const DefaultS3Key = "preflight/security-hub-collector-preflight.txt"
+
+// Result is the outcome of a single check
+type Result struct {
+	Team      string `json:"team,omitempty"`
+	AccountID string `json:"accountId,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Check     string `json:"check"`
+	Passed    bool   `json:"passed"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Checker verifies that the Collector can reach every account in a team map before collecting
+type Checker struct {
+	// Regions are the default Security Hub regions to check
+	Regions []string
+	// Overrides optionally provide per-account regions
+	Overrides *teams.AccountOverrides
+	// AccountRegions optionally gives the regions to check for each account ID, e.g. the regions a
+	// subset run selected, in place of Regions and Overrides
+	AccountRegions map[string][]string
+	// S3Bucket, if set, is checked for write access using the Collector's own credentials, by
+	// writing a marker object to S3Key. When S3Key is empty, the bucket isn't checked.
+	S3Bucket string
+	S3Key    string
+	S3Region string
+	// Concurrency is the number of accounts checked at once
+	Concurrency int
+	// EndpointURL, if set, replaces the STS, Security Hub and S3 endpoints, e.g. for a local stub
+	EndpointURL string
+}
+
+// Run checks every account in the map of Accounts to team names, plus the S3 bucket if one is
+// configured, and returns the results sorted by team, account, region and check
+func (c *Checker) Run(ctx context.Context, accountsToTeams map[teams.Account]string) []Result {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	type job struct {
+		account  teams.Account
+		teamName string
+	}
+	jobs := make(chan job)
+
+	var mu sync.Mutex
+	var results []Result
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				accountResults := c.checkAccount(ctx, j.account, j.teamName)
+				mu.Lock()
+				results = append(results, accountResults...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for account, teamName := range accountsToTeams {
+		jobs <- job{account: account, teamName: teamName}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if c.S3Bucket != "" && c.S3Key != "" {
+		results = append(results, c.checkS3Write(ctx))
+	}
+
+	slices.SortFunc(results, func(a, b Result) int {
+		return strings.Compare(
+			strings.Join([]string{a.Team, a.AccountID, a.Region, a.Check}, "\x00"),
+			strings.Join([]string{b.Team, b.AccountID, b.Region, b.Check}, "\x00"),
+		)
+	})
+
+	return results
+}
+
+// checkAccount assumes the account's role and calls DescribeHub in each of its regions
+func (c *Checker) checkAccount(ctx context.Context, account teams.Account, teamName string) []Result {
+	regions := c.Overrides.RegionsFor(account, c.Regions)
+	if c.AccountRegions != nil {
+		regions = c.AccountRegions[account.ID]
+	}
+	if len(regions) == 0 {
+		return []Result{{Team: teamName, AccountID: account.ID, Check: CheckDescribeHub, Reason: "no regions to check"}}
+	}
+
+	assumeRole := Result{Team: teamName, AccountID: account.ID, Check: CheckAssumeRole}
+	var creds aws.CredentialsProvider
+	if account.RoleARN != "" {
+		provider, err := client.MakeAssumeRoleProvider(regions[0], account.RoleARN, account.ExternalID, c.configOptions()...)
+		if err == nil {
+			_, err = provider.Retrieve(ctx)
+		}
+		if err != nil {
+			assumeRole.Reason = fmt.Sprintf("could not assume %s: %s", account.RoleARN, errorReason(err))
+			return []Result{assumeRole}
+		}
+		creds = provider
+	}
+	assumeRole.Passed = true
+	results := []Result{assumeRole}
+
+	for _, region := range regions {
+		describeHub := Result{Team: teamName, AccountID: account.ID, Region: region, Check: CheckDescribeHub}
+		secHubClient, err := client.MakeSecurityHubClientWithCredentials(region, creds, c.configOptions()...)
+		if err == nil {
+			_, err = secHubClient.DescribeHub(ctx, &securityhub.DescribeHubInput{})
+		}
+		if err != nil {
+			describeHub.Reason = errorReason(err)
+		} else {
+			describeHub.Passed = true
+		}
+		results = append(results, describeHub)
+	}
+
+	return results
+}
+
+// checkS3Write writes a small marker object to S3Key in the bucket. The Collector's bucket policy
+// only allows PutObject, so the marker is overwritten on each run rather than deleted.
+func (c *Checker) checkS3Write(ctx context.Context) Result {
+	key := c.S3Key
+	result := Result{Region: c.S3Region, Check: CheckS3Write}
+
+	s3Client, err := client.MakeS3Client(c.S3Region, c.configOptions()...)
+	if err == nil {
+		_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(c.S3Bucket),
+			Key:    aws.String(key),
+			Body:   strings.NewReader("security-hub-collector preflight check at " + time.Now().UTC().Format(time.RFC3339)),
+		})
+	}
+	if err != nil {
+		result.Reason = fmt.Sprintf("could not write s3://%s/%s: %s", c.S3Bucket, key, errorReason(err))
+	} else {
+		result.Passed = true
+	}
+	return result
+}
+
+// configOptions returns the SDK config options used for every client
+func (c *Checker) configOptions() []func(*config.LoadOptions) error {
+	if c.EndpointURL == "" {
+		return nil
+	}
+	return []func(*config.LoadOptions) error{config.WithBaseEndpoint(c.EndpointURL)}
+}
+
+// errorReason shortens an AWS SDK error to its API error code and message when possible
+func errorReason(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("%s: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+	}
+	return err.Error()
+}
+
+// Failed returns the number of failed checks
+func Failed(results []Result) int {
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteTable writes the results as an aligned text table
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEAM\tACCOUNT\tREGION\tCHECK\tRESULT\tREASON")
+	for _, r := range results {
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", dash(r.Team), dash(r.AccountID), dash(r.Region), r.Check, status, r.Reason)
+	}
+	fmt.Fprintf(tw, "\n%d checks, %d failed\n", len(results), Failed(results))
+	return tw.Flush()
+}
+
+// dash returns "-" for empty table cells
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
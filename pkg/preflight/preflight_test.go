@@ -0,0 +1,177 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASSUMEDACCESSKEY</AccessKeyId>
+      <SecretAccessKey>assumed-secret</SecretAccessKey>
+      <SessionToken>assumed-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::000000000011:assumed-role/CustomRole/collector</Arn>
+      <AssumedRoleId>AROATEST:collector</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata>
+    <RequestId>test-request</RequestId>
+  </ResponseMetadata>
+</AssumeRoleResponse>`
+
+const accessDeniedResponse = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>AccessDenied</Code>
+    <Message>not authorized to assume role</Message>
+  </Error>
+  <RequestId>test-request</RequestId>
+</ErrorResponse>`
+
+// newStubServer returns a stub for the STS, Security Hub and S3 APIs. Assuming deniedRoleARN fails,
+// and DescribeHub fails in disabledRegion.
+func newStubServer(t *testing.T, deniedRoleARN, disabledRegion string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("could not read STS request: %s", err)
+			}
+			form, err := parseForm(string(body))
+			if err != nil || form["Action"] != "AssumeRole" {
+				http.Error(w, "unexpected STS request", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/xml")
+			if form["RoleArn"] == deniedRoleARN {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, accessDeniedResponse)
+				return
+			}
+			fmt.Fprint(w, assumeRoleResponse)
+		case r.Method == http.MethodGet && r.URL.Path == "/accounts":
+			w.Header().Set("Content-Type", "application/json")
+			if strings.Contains(r.Header.Get("Authorization"), "/"+disabledRegion+"/securityhub/") {
+				w.Header().Set("X-Amzn-Errortype", "InvalidAccessException")
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"Message":"Account is not subscribed to AWS Security Hub"}`)
+				return
+			}
+			fmt.Fprint(w, `{"HubArn":"arn:aws:securityhub:us-east-1:000000000011:hub/default","SubscribedAt":"2020-01-01T00:00:00.000Z"}`)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/test-bucket/"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "unexpected request", http.StatusNotFound)
+		}
+	}))
+}
+
+// parseForm parses a URL-encoded request body into a map
+func parseForm(body string) (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err = req.ParseForm()
+	if err != nil {
+		return nil, err
+	}
+	form := make(map[string]string)
+	for k := range req.PostForm {
+		form[k] = req.PostForm.Get(k)
+	}
+	return form, nil
+}
+
+func TestRun(t *testing.T) {
+	// use static credentials and no shared config so the test never reaches real AWS
+	t.Setenv("AWS_ACCESS_KEY_ID", "TESTACCESSKEY")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	t.Setenv("AWS_MAX_ATTEMPTS", "1")
+
+	server := newStubServer(t, "arn:aws:iam::000000000012:role/CustomRole", "us-west-2")
+	defer server.Close()
+
+	accountsToTeams := map[teams.Account]string{
+		{ID: "000000000011", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"}:  "Test Team 1",
+		{ID: "000000000012", Environment: "prod", RoleARN: "arn:aws:iam::000000000012:role/CustomRole"}: "Test Team 1",
+	}
+
+	checker := Checker{
+		Regions:     []string{"us-east-1", "us-west-2"},
+		S3Bucket:    "test-bucket",
+		S3Key:       DefaultS3Key,
+		S3Region:    "us-east-1",
+		Concurrency: 2,
+		EndpointURL: server.URL,
+	}
+	actual := checker.Run(context.Background(), accountsToTeams)
+
+	expected := []Result{
+		{Region: "us-east-1", Check: CheckS3Write, Passed: true},
+		{Team: "Test Team 1", AccountID: "000000000011", Check: CheckAssumeRole, Passed: true},
+		{Team: "Test Team 1", AccountID: "000000000011", Region: "us-east-1", Check: CheckDescribeHub, Passed: true},
+		{Team: "Test Team 1", AccountID: "000000000011", Region: "us-west-2", Check: CheckDescribeHub, Reason: "InvalidAccessException: Account is not subscribed to AWS Security Hub"},
+		{Team: "Test Team 1", AccountID: "000000000012", Check: CheckAssumeRole, Reason: "could not assume arn:aws:iam::000000000012:role/CustomRole: AccessDenied: not authorized to assume role"},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("Expected preflight results did not match actual: %s", diff)
+	}
+
+	if failed := Failed(actual); failed != 2 {
+		t.Errorf("ERROR: expected 2 failed checks, got %d", failed)
+	}
+
+	var b bytes.Buffer
+	err := WriteTable(&b, actual)
+	if err != nil {
+		t.Fatalf("ERROR: could not write results table: %s", err)
+	}
+	if !strings.Contains(b.String(), "5 checks, 2 failed") {
+		t.Errorf("ERROR: expected results table to summarize failures, got:\n%s", b.String())
+	}
+
+	// only the regions a subset run selected are checked, even if others would fail
+	subset := Checker{
+		Regions:        []string{"us-east-1", "us-west-2"},
+		AccountRegions: map[string][]string{"000000000011": {"us-east-1"}},
+		EndpointURL:    server.URL,
+	}
+	actual = subset.Run(context.Background(), map[teams.Account]string{
+		{ID: "000000000011", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"}: "Test Team 1",
+	})
+	expected = []Result{
+		{Team: "Test Team 1", AccountID: "000000000011", Check: CheckAssumeRole, Passed: true},
+		{Team: "Test Team 1", AccountID: "000000000011", Region: "us-east-1", Check: CheckDescribeHub, Passed: true},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("ERROR: subset preflight results mismatch (-expected +actual):\n%s", diff)
+	}
+
+	// without a marker key the bucket isn't written to
+	checker.S3Key = ""
+	for _, result := range checker.Run(context.Background(), accountsToTeams) {
+		if result.Check == CheckS3Write {
+			t.Errorf("ERROR: expected no S3 write check without an S3 key, got %+v", result)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package securityhubcollector
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+)
+
+// Deduplication keys. Rows with the same key are duplicates of each other.
+const (
+	// DedupKeyControl identifies a row by its control ID, resource ID and account, which collapses
+	// control findings for global resources that are reported in every region, and consolidated
+	// control findings reported by more than one product
+	DedupKeyControl = "control"
+	// DedupKeyGenerator identifies a row by its finding's generator ID and resource ID
+	DedupKeyGenerator = "generator"
+)
+
+// DedupKeys lists the supported deduplication keys
+var DedupKeys = []string{DedupKeyControl, DedupKeyGenerator}
+
+// deduplicator holds back rows until all findings are collected, keeping only the most recently
+// updated row for each key
+type deduplicator struct {
+	key     string
+	entries map[string]*dedupEntry
+	// order is the keys in the order they were first seen, so the output is stable
+	order []string
+}
+
+// dedupEntry is the row kept for a key, along with the regions of all of its duplicates
+type dedupEntry struct {
+	record     FindingRecord
+	duplicates int
+	regions    map[string]bool
+}
+
+func newDeduplicator(key string) (*deduplicator, error) {
+	if !slices.Contains(DedupKeys, key) {
+		return nil, fmt.Errorf("unknown deduplication key %q; expected one of %s", key, strings.Join(DedupKeys, ", "))
+	}
+	return &deduplicator{key: key, entries: make(map[string]*dedupEntry)}, nil
+}
+
+// rowKey returns the deduplication key of a row. Rows without a control ID or generator ID are
+// only duplicates of rows for the same finding and resource.
+func (d *deduplicator) rowKey(finding types.AwsSecurityFinding, record FindingRecord) string {
+	switch {
+	case d.key == DedupKeyControl && record.ControlID != "":
+		return strings.Join([]string{"control", record.ControlID, record.ResourceID, record.AWSAccountID}, "\x00")
+	case d.key == DedupKeyGenerator && aws.ToString(finding.GeneratorId) != "":
+		return strings.Join([]string{"generator", aws.ToString(finding.GeneratorId), record.ResourceID}, "\x00")
+	default:
+		return strings.Join([]string{"finding", record.ID, record.ResourceID}, "\x00")
+	}
+}
+
+// add adds a row for finding, replacing the row kept for its key if this one was updated more
+// recently
+func (d *deduplicator) add(finding types.AwsSecurityFinding, record FindingRecord) {
+	key := d.rowKey(finding, record)
+	entry, ok := d.entries[key]
+	if !ok {
+		d.entries[key] = &dedupEntry{record: record, regions: map[string]bool{record.Region: true}}
+		d.order = append(d.order, key)
+		return
+	}
+
+	entry.duplicates++
+	entry.regions[record.Region] = true
+	// timestamps are standardized to a fixed-width UTC layout, so they sort as strings
+	if record.UpdatedAt > entry.record.UpdatedAt {
+		entry.record = record
+	}
+}
+
+// records returns the rows kept, with their Duplicate Count and Duplicate Regions columns set,
+// and the number of duplicate rows removed
+func (d *deduplicator) records() ([]FindingRecord, int) {
+	records := make([]FindingRecord, 0, len(d.order))
+	removed := 0
+	for _, key := range d.order {
+		entry := d.entries[key]
+		regions := make([]string, 0, len(entry.regions))
+		for region := range entry.regions {
+			regions = append(regions, region)
+		}
+		slices.Sort(regions)
+
+		record := entry.record
+		record.DuplicateCount = strconv.Itoa(entry.duplicates)
+		record.DuplicateRegions = strings.Join(regions, ", ")
+		records = append(records, record)
+		removed += entry.duplicates
+	}
+	return records, removed
+}
@@ -0,0 +1,119 @@
+package securityhubcollector
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+// dedupFinding returns a finding for a control on a global resource, as reported in one region
+func dedupFinding(id, generatorID, controlID, region, updatedAt string) types.AwsSecurityFinding {
+	return types.AwsSecurityFinding{
+		Id:           aws.String(id),
+		GeneratorId:  aws.String(generatorID),
+		AwsAccountId: aws.String("000000000001"),
+		Region:       aws.String(region),
+		UpdatedAt:    aws.String(updatedAt),
+		Compliance:   &types.Compliance{Status: types.ComplianceStatusFailed, SecurityControlId: aws.String(controlID)},
+		Resources: []types.Resource{
+			{Id: aws.String("arn:aws:iam::000000000001:root"), Type: aws.String("AwsAccount")},
+		},
+	}
+}
+
+func TestDeduplication(t *testing.T) {
+	findings := []types.AwsSecurityFinding{
+		dedupFinding("east", "security-control/IAM.6", "IAM.6", "us-east-1", "2023-01-01T00:00:00.000Z"),
+		dedupFinding("west", "security-control/IAM.6", "IAM.6", "us-west-2", "2023-01-02T00:00:00.000Z"),
+		dedupFinding("config", "config-rule/iam-root-mfa", "IAM.6", "us-east-1", "2022-12-31T00:00:00.000Z"),
+		// without a control ID, findings are only duplicates of themselves
+		dedupFinding("other-1", "custom", "", "us-east-1", "2023-01-01T00:00:00.000Z"),
+		dedupFinding("other-2", "custom", "", "us-west-2", "2023-01-01T00:00:00.000Z"),
+	}
+
+	testCases := []struct {
+		key        string
+		expected   [][]string
+		duplicates int
+	}{
+		{
+			key: DedupKeyControl,
+			expected: [][]string{
+				{"ID", "Region", "Duplicate Count", "Duplicate Regions"},
+				{"west", "us-west-2", "2", "us-east-1, us-west-2"},
+				{"other-1", "us-east-1", "0", "us-east-1"},
+				{"other-2", "us-west-2", "0", "us-west-2"},
+			},
+			duplicates: 2,
+		},
+		{
+			key: DedupKeyGenerator,
+			expected: [][]string{
+				{"ID", "Region", "Duplicate Count", "Duplicate Regions"},
+				{"west", "us-west-2", "1", "us-east-1, us-west-2"},
+				{"config", "us-east-1", "0", "us-east-1"},
+				{"other-1", "us-east-1", "1", "us-east-1, us-west-2"},
+			},
+			duplicates: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "findings.tsv")
+			h := HubCollector{DedupKey: tc.key, Summary: summary.NewBuilder()}
+			if err := h.Initialize(fileName); err != nil {
+				t.Fatalf("ERROR: could not initialize HubCollector: %s", err)
+			}
+			rows, err := h.writeFindingsToOutput(findings, "Test Team", teams.Account{ID: "000000000001"})
+			if err != nil || rows != len(findings) {
+				t.Fatalf("ERROR: expected %d rows held back, got %d (%v)", len(findings), rows, err)
+			}
+			if err := h.WriteDeduplicated(); err != nil {
+				t.Fatalf("ERROR: could not write deduplicated rows: %s", err)
+			}
+			if err := h.FlushAndClose(); err != nil {
+				t.Fatalf("ERROR: could not close output file: %s", err)
+			}
+
+			headers, fileRows, err := ReadFindingsFile(fileName, FormatTSV)
+			if err != nil {
+				t.Fatalf("ERROR: could not read output file: %s", err)
+			}
+			columns := tc.expected[0]
+			actual := [][]string{columns}
+			for _, row := range fileRows {
+				var selected []string
+				for _, column := range columns {
+					for i, header := range headers {
+						if header == column {
+							selected = append(selected, row[i])
+						}
+					}
+				}
+				actual = append(actual, selected)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: rows mismatch (-expected +actual):\n%s", diff)
+			}
+
+			s := h.Summary.Summary()
+			if s.Rows != len(tc.expected)-1 || s.DuplicateRows != tc.duplicates {
+				t.Errorf("ERROR: expected %d rows and %d duplicates in the summary, got %d and %d", len(tc.expected)-1, tc.duplicates, s.Rows, s.DuplicateRows)
+			}
+		})
+	}
+}
+
+func TestUnknownDedupKey(t *testing.T) {
+	h := HubCollector{DedupKey: "title"}
+	if err := h.Initialize(filepath.Join(t.TempDir(), "findings.tsv")); err == nil {
+		t.Error("ERROR: expected an error for an unknown deduplication key")
+	}
+}
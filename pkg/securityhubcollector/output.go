@@ -0,0 +1,324 @@
+package securityhubcollector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Output formats for findings files
+const (
+	// FormatTSV is tab-delimited CSV, which is what QuickSight ingests
+	FormatTSV = "tsv"
+	// FormatCSV is comma-delimited CSV
+	FormatCSV = "csv"
+	// FormatJSONL is newline-delimited JSON, with one object per row keyed by column header
+	FormatJSONL = "jsonl"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTSV, FormatCSV, FormatJSONL}
+
+// rowWriter writes finding rows in a single output format
+type rowWriter interface {
+	WriteHeader(headers []string) error
+	Write(row []string) error
+	Flush() error
+}
+
+// newRowWriter returns a rowWriter for the given format
+func newRowWriter(w io.Writer, format string) (rowWriter, error) {
+	switch format {
+	case FormatTSV, "":
+		csvWriter := csv.NewWriter(w)
+		// use tab delimiters since we were seeing some INCORRECT_FIELD_COUNT
+		// errors on QuickSight ingestion due to unescaped commas in some fields
+		csvWriter.Comma = '\t'
+		return &csvRowWriter{csvWriter: csvWriter}, nil
+	case FormatCSV:
+		return &csvRowWriter{csvWriter: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		return &jsonlRowWriter{writer: bufio.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q; expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// csvRowWriter writes rows as delimited text
+type csvRowWriter struct {
+	csvWriter *csv.Writer
+}
+
+func (c *csvRowWriter) WriteHeader(headers []string) error {
+	return c.csvWriter.Write(headers)
+}
+
+func (c *csvRowWriter) Write(row []string) error {
+	return c.csvWriter.Write(row)
+}
+
+func (c *csvRowWriter) Flush() error {
+	c.csvWriter.Flush()
+	return c.csvWriter.Error()
+}
+
+// jsonlRowWriter writes rows as JSON objects, one per line, keeping the column order
+type jsonlRowWriter struct {
+	writer  *bufio.Writer
+	headers []string
+}
+
+func (j *jsonlRowWriter) WriteHeader(headers []string) error {
+	j.headers = headers
+	return nil
+}
+
+func (j *jsonlRowWriter) Write(row []string) error {
+	b, err := MarshalRow(j.headers, row)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	_, err = j.writer.Write(b)
+	return err
+}
+
+func (j *jsonlRowWriter) Flush() error {
+	return j.writer.Flush()
+}
+
+// MarshalRow encodes a row as a JSON object keyed by header, keeping the column order
+func MarshalRow(headers, row []string) ([]byte, error) {
+	if len(row) != len(headers) {
+		return nil, fmt.Errorf("row has %d fields but there are %d headers", len(row), len(headers))
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, header := range headers {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(row[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// WriteRows writes a header row and rows to w in the given format
+func WriteRows(w io.Writer, format string, headers []string, rows [][]string) error {
+	writer, err := newRowWriter(w, format)
+	if err != nil {
+		return err
+	}
+	err = writer.WriteHeader(headers)
+	if err != nil {
+		return fmt.Errorf("could not write headers: %v", err)
+	}
+	for _, row := range rows {
+		err = writer.Write(row)
+		if err != nil {
+			return fmt.Errorf("could not write row: %v", err)
+		}
+	}
+	return writer.Flush()
+}
+
+// FormatFromFileName guesses the format of a findings file from its extension, defaulting to TSV
+// since that is what the Collector writes by default. The Collector's own output is named .csv
+// but tab-delimited by default, so .csv files are told apart by their header row.
+func FormatFromFileName(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".jsonl", ".json":
+		return FormatJSONL
+	case ".csv":
+		return sniffDelimiter(fileName)
+	default:
+		return FormatTSV
+	}
+}
+
+// sniffDelimiter returns FormatCSV if the header row of a file has commas but no tabs, and
+// FormatTSV otherwise, including when the file can't be read
+func sniffDelimiter(fileName string) string {
+	f, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return FormatTSV
+	}
+	defer f.Close() //nolint
+
+	header, _ := bufio.NewReader(f).ReadString('\n')
+	if strings.Contains(header, ",") && !strings.Contains(header, "\t") {
+		return FormatCSV
+	}
+	return FormatTSV
+}
+
+// ReadFindingsFile reads a findings file in the given format and returns its headers and rows
+func ReadFindingsFile(fileName, format string) (headers []string, rows [][]string, err error) {
+	f, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open findings file: %v", err)
+	}
+	defer f.Close() //nolint
+
+	switch format {
+	case FormatTSV, FormatCSV, "":
+		reader := csv.NewReader(f)
+		if format != FormatCSV {
+			reader.Comma = '\t'
+		}
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read findings file: %v", err)
+		}
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("findings file %s has no header row", fileName)
+		}
+		return records[0], records[1:], nil
+	case FormatJSONL:
+		return readJSONL(f)
+	default:
+		return nil, nil, fmt.Errorf("unknown findings file format %q; expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// readJSONL reads newline-delimited JSON objects. The headers are the keys of the first object,
+// in order; later objects are read in the same column order.
+func readJSONL(r io.Reader) (headers []string, rows [][]string, err error) {
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		object, keys, err := decodeOrderedObject(decoder)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read findings file: %v", err)
+		}
+		if headers == nil {
+			headers = keys
+		}
+		row := make([]string, len(headers))
+		for i, header := range headers {
+			row[i] = object[header]
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows, nil
+}
+
+// decodeOrderedObject decodes a JSON object of strings, returning its values and its keys in order
+func decodeOrderedObject(decoder *json.Decoder) (map[string]string, []string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, nil, fmt.Errorf("expected a JSON object, got %v", token)
+	}
+
+	object := make(map[string]string)
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected a JSON object key, got %v", token)
+		}
+		var value string
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not decode value for %q: %v", key, err)
+		}
+		object[key] = value
+		keys = append(keys, key)
+	}
+
+	// consume the closing brace
+	_, err = decoder.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	return object, keys, nil
+}
+
+// ConvertFindingsFile rewrites a findings file in another format, keeping all of its columns
+func ConvertFindingsFile(inFileName, inFormat, outFileName, outFormat string) (err error) {
+	headers, rows, err := ReadFindingsFile(inFileName, inFormat)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Clean(outFileName))
+	if err != nil {
+		return fmt.Errorf("could not create output file: %v", err)
+	}
+	defer func() {
+		cerr := f.Close()
+		if err == nil && cerr != nil {
+			err = fmt.Errorf("could not close output file: %v", cerr)
+		}
+	}()
+
+	err = WriteRows(f, outFormat, headers, rows)
+	if err != nil {
+		return fmt.Errorf("could not write output file: %v", err)
+	}
+	return nil
+}
+
+// RecordsFromRows maps rows read from a findings file to FindingRecords by column header.
+// Columns that FindingRecord doesn't have are ignored, and missing columns are left empty.
+func RecordsFromRows(headers []string, rows [][]string) []FindingRecord {
+	t := reflect.TypeOf(FindingRecord{})
+	fieldIndexes := make([]int, len(headers))
+	for i, header := range headers {
+		fieldIndexes[i] = -1
+		for j := 0; j < t.NumField(); j++ {
+			if recordHeader(t.Field(j)) == header {
+				fieldIndexes[i] = j
+				break
+			}
+		}
+	}
+
+	records := make([]FindingRecord, 0, len(rows))
+	for _, row := range rows {
+		var record FindingRecord
+		v := reflect.ValueOf(&record).Elem()
+		for i, value := range row {
+			if i < len(fieldIndexes) && fieldIndexes[i] >= 0 {
+				v.Field(fieldIndexes[i]).SetString(value)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// ReadFindingRecords reads a findings file in the given format into FindingRecords
+func ReadFindingRecords(fileName, format string) ([]FindingRecord, error) {
+	headers, rows, err := ReadFindingsFile(fileName, format)
+	if err != nil {
+		return nil, err
+	}
+	return RecordsFromRows(headers, rows), nil
+}
@@ -0,0 +1,107 @@
+package securityhubcollector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testHeaders = []string{"Team", "ID", "Severity Label", "Description", "Extra Column"}
+
+var testRows = [][]string{
+	{"Test Team 1", "testID1", "HIGH", "Contains, a comma", "extra 1"},
+	{"Test Team 2", "testID2", "LOW", `Contains "quotes"` + "\tand a tab", "extra 2"},
+}
+
+// This function tests that findings files can be converted between every pair of formats without losing data
+func TestConvertFindingsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, inFormat := range Formats {
+		for _, outFormat := range Formats {
+			t.Run(inFormat+" to "+outFormat, func(t *testing.T) {
+				inFileName := filepath.Join(dir, "in."+inFormat)
+				outFileName := filepath.Join(dir, "out."+outFormat)
+
+				f, err := os.Create(inFileName)
+				if err != nil {
+					t.Fatalf("could not create input file: %s", err)
+				}
+				writer, err := newRowWriter(f, inFormat)
+				if err != nil {
+					t.Fatalf("could not create row writer: %s", err)
+				}
+				err = writer.WriteHeader(testHeaders)
+				if err != nil {
+					t.Fatalf("could not write headers: %s", err)
+				}
+				for _, row := range testRows {
+					err = writer.Write(row)
+					if err != nil {
+						t.Fatalf("could not write row: %s", err)
+					}
+				}
+				err = writer.Flush()
+				if err != nil {
+					t.Fatalf("could not flush writer: %s", err)
+				}
+				f.Close()
+
+				err = ConvertFindingsFile(inFileName, inFormat, outFileName, outFormat)
+				if err != nil {
+					t.Fatalf("could not convert findings file: %s", err)
+				}
+
+				headers, rows, err := ReadFindingsFile(outFileName, outFormat)
+				if err != nil {
+					t.Fatalf("could not read converted findings file: %s", err)
+				}
+				if diff := cmp.Diff(testHeaders, headers); diff != "" {
+					t.Errorf("Expected headers did not match actual: %s", diff)
+				}
+				if diff := cmp.Diff(testRows, rows); diff != "" {
+					t.Errorf("Expected rows did not match actual: %s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestRecordsFromRows(t *testing.T) {
+	expected := []FindingRecord{
+		{Team: "Test Team 1", ID: "testID1", SeverityLabel: "HIGH", Description: "Contains, a comma"},
+		{Team: "Test Team 2", ID: "testID2", SeverityLabel: "LOW", Description: `Contains "quotes"` + "\tand a tab"},
+	}
+	actual := RecordsFromRows(testHeaders, testRows)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Expected records did not match actual: %s", diff)
+	}
+}
+
+func TestFormatFromFileName(t *testing.T) {
+	dir := t.TempDir()
+	tsvNamedCSV := filepath.Join(dir, "SecurityHub-Findings_10-19-2026.csv")
+	commaDelimited := filepath.Join(dir, "findings.CSV")
+	for fileName, header := range map[string]string{tsvNamedCSV: "Team\tID\tDescription\n", commaDelimited: "Team,ID,Description\n"} {
+		if err := os.WriteFile(fileName, []byte(header), 0600); err != nil {
+			t.Fatalf("ERROR: could not write test file: %s", err)
+		}
+	}
+
+	testCases := map[string]string{
+		tsvNamedCSV:                 FormatTSV,
+		commaDelimited:              FormatCSV,
+		filepath.Join(dir, "x.csv"): FormatTSV,
+		"findings.tsv":              FormatTSV,
+		"findings":                  FormatTSV,
+		"findings.jsonl":            FormatJSONL,
+		"findings.JSON":             FormatJSONL,
+	}
+	for fileName, expected := range testCases {
+		if actual := FormatFromFileName(fileName); actual != expected {
+			t.Errorf("ERROR: expected format %s for %s, got %s", expected, fileName, actual)
+		}
+	}
+}
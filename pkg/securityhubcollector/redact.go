@@ -0,0 +1,195 @@
+package securityhubcollector
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// Redaction methods
+const (
+	// RedactHMAC replaces identifiers with a keyed hash of their value, so they line up across
+	// runs that use the same key
+	RedactHMAC = "hmac"
+	// RedactMask replaces identifiers with numbered pseudonyms, e.g. account-1, that only line up
+	// within a run
+	RedactMask = "mask"
+)
+
+// RedactMethods lists the supported redaction methods
+var RedactMethods = []string{RedactHMAC, RedactMask}
+
+// identifierColumns are the columns whose whole value is an identifier, and the kind of
+// identifier each holds. Team Map Source is included since team sources name internal URLs and
+// file paths.
+var identifierColumns = map[string]string{
+	"AWS Account ID":  "account",
+	"ID":              "finding",
+	"Resource ID":     "resource",
+	"Resource Name":   "resource",
+	"Resource Owner":  "owner",
+	"Resource Image":  "image",
+	"Team Map Source": "source",
+}
+
+var (
+	// arnPattern matches ARNs in free text
+	arnPattern = regexp.MustCompile(`arn:aws[a-z-]*:[^\s,;"'<>()\[\]]+`)
+	// accountIDPattern matches AWS account IDs in free text
+	accountIDPattern = regexp.MustCompile(`\b\d{12}\b`)
+	// urlPattern matches URLs in free text
+	urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s,;"'<>()\[\]]+`)
+)
+
+// publicURLHosts are the hosts, along with their subdomains, of URLs that are kept in redacted
+// output, like the AWS documentation that remediation URLs point to
+var publicURLHosts = []string{"aws.amazon.com"}
+
+// RedactionProfile describes how findings are redacted for sharing outside the program. Account
+// IDs, resource IDs and ARNs are replaced with the same pseudonym everywhere they appear, so
+// cross-references still line up.
+type RedactionProfile struct {
+	// Method is one of RedactMethods
+	Method string
+	// Key is the HMAC key. It is required for RedactHMAC.
+	Key []byte
+	// DropColumns are the headers of columns, e.g. Description, whose values are left empty
+	DropColumns []string
+}
+
+// Validate checks the profile's method, key and columns
+func (p RedactionProfile) Validate() error {
+	if !slices.Contains(RedactMethods, p.Method) {
+		return fmt.Errorf("unknown redaction method %q; expected one of %s", p.Method, strings.Join(RedactMethods, ", "))
+	}
+	if p.Method == RedactHMAC && len(p.Key) == 0 {
+		return fmt.Errorf("redaction method %s requires a key", RedactHMAC)
+	}
+	headers := FindingRecord{}.GetHeaders()
+	for _, column := range p.DropColumns {
+		if !slices.Contains(headers, column) {
+			return fmt.Errorf("unknown column %q to drop from redacted output", column)
+		}
+	}
+	return nil
+}
+
+// redactor applies a RedactionProfile, remembering the pseudonyms it has given out so they are
+// consistent across a run
+type redactor struct {
+	profile    RedactionProfile
+	pseudonyms map[string]string
+	counts     map[string]int
+}
+
+func newRedactor(profile RedactionProfile) (*redactor, error) {
+	err := profile.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &redactor{profile: profile, pseudonyms: make(map[string]string), counts: make(map[string]int)}, nil
+}
+
+// record returns a redacted copy of a record. Dropped columns are emptied rather than removed, so
+// redacted output has the same columns as the full output.
+func (r *redactor) record(record FindingRecord) FindingRecord {
+	v := reflect.ValueOf(&record).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		header := recordHeader(t.Field(i))
+		field := v.Field(i)
+		switch kind, ok := identifierColumns[header]; {
+		case slices.Contains(r.profile.DropColumns, header):
+			field.SetString("")
+		case ok:
+			field.SetString(r.identifier(kind, field.String()))
+		default:
+			field.SetString(r.text(field.String()))
+		}
+	}
+	return record
+}
+
+// identifier redacts a value that is an identifier of the given kind
+func (r *redactor) identifier(kind, value string) string {
+	switch {
+	case value == "":
+		return ""
+	case arn.IsARN(value):
+		return r.arn(value)
+	case accountIDPattern.MatchString(value) && len(value) == 12:
+		return r.pseudonym("account", value)
+	default:
+		return r.pseudonym(kind, value)
+	}
+}
+
+// text redacts the URLs, ARNs and account IDs in free text
+func (r *redactor) text(value string) string {
+	value = urlPattern.ReplaceAllStringFunc(value, r.url)
+	value = arnPattern.ReplaceAllStringFunc(value, r.arn)
+	return accountIDPattern.ReplaceAllStringFunc(value, func(accountID string) string {
+		return r.pseudonym("account", accountID)
+	})
+}
+
+// arn redacts the account ID and resource of an ARN, keeping its partition, service and region.
+// ARNs owned by AWS, like managed policies, and Security Hub product ARNs are kept as they are.
+func (r *redactor) arn(value string) string {
+	parsed, err := arn.Parse(value)
+	if err != nil {
+		return r.pseudonym("arn", value)
+	}
+	if parsed.AccountID == "aws" || strings.HasPrefix(parsed.Resource, "product/") {
+		return value
+	}
+	if parsed.AccountID != "" {
+		parsed.AccountID = r.pseudonym("account", parsed.AccountID)
+	}
+	parsed.Resource = r.pseudonym("resource", parsed.Resource)
+	return parsed.String()
+}
+
+// url redacts a URL unless its host is one of publicURLHosts
+func (r *redactor) url(value string) string {
+	parsed, err := url.Parse(value)
+	if err == nil {
+		host := strings.ToLower(parsed.Hostname())
+		for _, public := range publicURLHosts {
+			if host == public || strings.HasSuffix(host, "."+public) {
+				return value
+			}
+		}
+	}
+	return r.pseudonym("url", value)
+}
+
+// pseudonym returns the pseudonym for a value of the given kind: a truncated HMAC of the value,
+// or the next number for the kind the first time the value is seen
+func (r *redactor) pseudonym(kind, value string) string {
+	key := kind + ":" + value
+	if pseudonym, ok := r.pseudonyms[key]; ok {
+		return pseudonym
+	}
+
+	var pseudonym string
+	if r.profile.Method == RedactHMAC {
+		mac := hmac.New(sha256.New, r.profile.Key)
+		mac.Write([]byte(key))
+		pseudonym = kind + "-" + hex.EncodeToString(mac.Sum(nil))[:16]
+	} else {
+		r.counts[kind]++
+		pseudonym = kind + "-" + strconv.Itoa(r.counts[kind])
+	}
+	r.pseudonyms[key] = pseudonym
+	return pseudonym
+}
@@ -0,0 +1,151 @@
+package securityhubcollector
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+func TestRedactMask(t *testing.T) {
+	r, err := newRedactor(RedactionProfile{Method: RedactMask})
+	if err != nil {
+		t.Fatalf("ERROR: could not create redactor: %s", err)
+	}
+
+	record := r.record(FindingRecord{
+		ID:             "arn:aws:securityhub:us-east-1:111111111111:subscription/aws-foundational-security-best-practices/v/1.0.0/S3.8/finding/abc",
+		ProductARN:     "arn:aws:securityhub:us-east-1::product/aws/securityhub",
+		Title:          "S3.8 S3 general purpose buckets should block public access",
+		Description:    "Bucket arn:aws:s3:::internal-logs in account 111111111111 is readable by 222222222222. See https://wiki.internal.example.com/s3 and https://docs.aws.amazon.com/securityhub/latest/userguide/s3-controls.html.",
+		RemediationURL: "https://wiki.internal.example.com/s3",
+		ResourceID:     "arn:aws:s3:::internal-logs",
+		AWSAccountID:   "111111111111",
+		Region:         "us-east-1",
+		TeamMapSource:  "teams-api:https://teams.internal.example.com",
+		ResourceName:   "internal-logs",
+		ResourceOwner:  "bob@example.com",
+		ResourceImage:  "111111111111.dkr.ecr.us-east-1.amazonaws.com/internal-api:v1.2.3",
+	})
+	expected := FindingRecord{
+		ID:             "arn:aws:securityhub:us-east-1:account-1:resource-1",
+		ProductARN:     "arn:aws:securityhub:us-east-1::product/aws/securityhub",
+		Title:          "S3.8 S3 general purpose buckets should block public access",
+		Description:    "Bucket arn:aws:s3:::resource-2 in account account-1 is readable by account-2. See url-1 and https://docs.aws.amazon.com/securityhub/latest/userguide/s3-controls.html.",
+		RemediationURL: "url-1",
+		ResourceID:     "arn:aws:s3:::resource-2",
+		AWSAccountID:   "account-1",
+		Region:         "us-east-1",
+		TeamMapSource:  "source-1",
+		// the bucket name lines up with the bucket's ARN
+		ResourceName:  "resource-2",
+		ResourceOwner: "owner-1",
+		ResourceImage: "image-1",
+	}
+	if diff := cmp.Diff(expected, record); diff != "" {
+		t.Errorf("ERROR: redacted record mismatch (-expected +actual):\n%s", diff)
+	}
+
+	// dropped columns are emptied
+	r, err = newRedactor(RedactionProfile{Method: RedactMask, DropColumns: []string{"Description", "Remediation URL"}})
+	if err != nil {
+		t.Fatalf("ERROR: could not create redactor: %s", err)
+	}
+	record = r.record(FindingRecord{Title: "Title", Description: "Internal details", RemediationURL: "https://wiki.internal.example.com/s3"})
+	if diff := cmp.Diff(FindingRecord{Title: "Title"}, record); diff != "" {
+		t.Errorf("ERROR: dropped columns mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestRedactHMAC(t *testing.T) {
+	profile := RedactionProfile{Method: RedactHMAC, Key: []byte("secret")}
+	first, err := newRedactor(profile)
+	if err != nil {
+		t.Fatalf("ERROR: could not create redactor: %s", err)
+	}
+	second, err := newRedactor(profile)
+	if err != nil {
+		t.Fatalf("ERROR: could not create redactor: %s", err)
+	}
+	other, err := newRedactor(RedactionProfile{Method: RedactHMAC, Key: []byte("other")})
+	if err != nil {
+		t.Fatalf("ERROR: could not create redactor: %s", err)
+	}
+
+	record := FindingRecord{AWSAccountID: "111111111111", ResourceID: "i-0123456789abcdef0"}
+	a, b, c := first.record(record), second.record(record), other.record(record)
+	if a != b {
+		t.Errorf("ERROR: expected the same key to give the same pseudonyms, got %+v and %+v", a, b)
+	}
+	if a.AWSAccountID == c.AWSAccountID {
+		t.Errorf("ERROR: expected different keys to give different pseudonyms, got %q", a.AWSAccountID)
+	}
+	if !strings.HasPrefix(a.AWSAccountID, "account-") || strings.Contains(a.AWSAccountID, "111111111111") {
+		t.Errorf("ERROR: unexpected account pseudonym %q", a.AWSAccountID)
+	}
+	if !strings.HasPrefix(a.ResourceID, "resource-") {
+		t.Errorf("ERROR: unexpected resource pseudonym %q", a.ResourceID)
+	}
+}
+
+func TestRedactionProfileValidate(t *testing.T) {
+	for _, profile := range []RedactionProfile{
+		{Method: "encrypt"},
+		{Method: RedactHMAC},
+		{Method: RedactMask, DropColumns: []string{"Notes"}},
+	} {
+		if err := profile.Validate(); err == nil {
+			t.Errorf("ERROR: expected an error validating %+v", profile)
+		}
+	}
+}
+
+func TestRedactedOutput(t *testing.T) {
+	dir := t.TempDir()
+	h := HubCollector{
+		Redaction:              &RedactionProfile{Method: RedactMask, DropColumns: []string{"Description"}},
+		RedactedOutputFileName: filepath.Join(dir, "redacted.tsv"),
+	}
+	if err := h.Initialize(filepath.Join(dir, "findings.tsv")); err != nil {
+		t.Fatalf("ERROR: could not initialize HubCollector: %s", err)
+	}
+	findings := []types.AwsSecurityFinding{{
+		Id:           aws.String("abc123"),
+		AwsAccountId: aws.String("111111111111"),
+		Description:  aws.String("Internal details"),
+		Resources:    []types.Resource{{Id: aws.String("arn:aws:ec2:us-east-1:111111111111:instance/i-1"), Region: aws.String("us-east-1")}},
+	}}
+	if _, err := h.writeFindingsToOutput(findings, "Test Team", teams.Account{ID: "111111111111"}); err != nil {
+		t.Fatalf("ERROR: could not write findings: %s", err)
+	}
+	if err := h.FlushAndClose(); err != nil {
+		t.Fatalf("ERROR: could not close output files: %s", err)
+	}
+
+	expected := map[string][]string{
+		"findings.tsv": {"abc123", "Internal details", "arn:aws:ec2:us-east-1:111111111111:instance/i-1", "111111111111"},
+		"redacted.tsv": {"finding-1", "", "arn:aws:ec2:us-east-1:account-1:resource-1", "account-1"},
+	}
+	for fileName, values := range expected {
+		headers, rows, err := ReadFindingsFile(filepath.Join(dir, fileName), FormatTSV)
+		if err != nil {
+			t.Fatalf("ERROR: could not read %s: %s", fileName, err)
+		}
+		var actual []string
+		for _, column := range []string{"ID", "Description", "Resource ID", "AWS Account ID"} {
+			for i, header := range headers {
+				if header == column {
+					actual = append(actual, rows[0][i])
+				}
+			}
+		}
+		if diff := cmp.Diff(values, actual); diff != "" {
+			t.Errorf("ERROR: %s mismatch (-expected +actual):\n%s", fileName, diff)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package securityhubcollector
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+)
+
+// DefaultOwnerTagKey is the resource tag key whose value is written to the Resource Owner column
+// when HubCollector.OwnerTagKey isn't set
+const DefaultOwnerTagKey = "Owner"
+
+// resourceSummary is the resource details that are flattened into the generic resource columns.
+// Public is "true" or "false", or empty if the details don't say whether the resource is exposed.
+type resourceSummary struct {
+	Name   string
+	Image  string
+	Public string
+}
+
+// resourceExtractors summarize the details of common resource types. Each returns false if the
+// details aren't for its resource type.
+var resourceExtractors = []func(*types.ResourceDetails) (resourceSummary, bool){
+	ec2InstanceSummary,
+	s3BucketSummary,
+	iamRoleSummary,
+	lambdaFunctionSummary,
+	ecrImageSummary,
+	rdsInstanceSummary,
+	ecsTaskDefinitionSummary,
+}
+
+// setResourceColumns sets the Resource Name, Resource Owner, Resource Image and Publicly Exposed
+// columns of a record from a resource's details and tags. Resources without a name in their
+// details are named by their Name tag.
+func (h *HubCollector) setResourceColumns(record *FindingRecord, r types.Resource) {
+	var summary resourceSummary
+	if r.Details != nil {
+		for _, extract := range resourceExtractors {
+			if s, ok := extract(r.Details); ok {
+				summary = s
+				break
+			}
+		}
+	}
+	if summary.Name == "" {
+		summary.Name = tagValue(r.Tags, "Name")
+	}
+
+	ownerTagKey := h.OwnerTagKey
+	if ownerTagKey == "" {
+		ownerTagKey = DefaultOwnerTagKey
+	}
+
+	record.ResourceName = summary.Name
+	record.ResourceOwner = tagValue(r.Tags, ownerTagKey)
+	record.ResourceImage = summary.Image
+	record.PubliclyExposed = summary.Public
+}
+
+// tagValue returns the value of the tag with the given key, ignoring case
+func tagValue(tags map[string]string, key string) string {
+	if value, ok := tags[key]; ok {
+		return strings.TrimSpace(value)
+	}
+	for k, value := range tags {
+		if strings.EqualFold(k, key) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// ec2InstanceSummary summarizes an EC2 instance by its AMI. It is publicly exposed if it has a
+// public IPv4 address.
+func ec2InstanceSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	instance := details.AwsEc2Instance
+	if instance == nil {
+		return resourceSummary{}, false
+	}
+	public := false
+	for _, address := range instance.IpV4Addresses {
+		ip := net.ParseIP(address)
+		if ip != nil && ip.IsGlobalUnicast() && !ip.IsPrivate() {
+			public = true
+		}
+	}
+	return resourceSummary{Image: aws.ToString(instance.ImageId), Public: strconv.FormatBool(public)}, true
+}
+
+// publicACLGroups are the grantees of S3 ACLs that make a bucket public
+var publicACLGroups = []string{
+	"http://acs.amazonaws.com/groups/global/AllUsers",
+	"http://acs.amazonaws.com/groups/global/AuthenticatedUsers",
+}
+
+// s3BucketSummary summarizes an S3 bucket. It isn't publicly exposed if all public access is
+// blocked, and is if its ACL grants access to everyone and public ACLs aren't ignored. Otherwise,
+// exposure depends on the bucket policy, which the details don't include.
+func s3BucketSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	bucket := details.AwsS3Bucket
+	if bucket == nil {
+		return resourceSummary{}, false
+	}
+	summary := resourceSummary{Name: aws.ToString(bucket.Name)}
+
+	block := bucket.PublicAccessBlockConfiguration
+	if block == nil {
+		block = &types.AwsS3AccountPublicAccessBlockDetails{}
+	}
+	switch {
+	case aws.ToBool(block.BlockPublicAcls) && aws.ToBool(block.IgnorePublicAcls) &&
+		aws.ToBool(block.BlockPublicPolicy) && aws.ToBool(block.RestrictPublicBuckets):
+		summary.Public = "false"
+	case !aws.ToBool(block.IgnorePublicAcls) && containsAny(aws.ToString(bucket.AccessControlList), publicACLGroups):
+		summary.Public = "true"
+	}
+	return summary, true
+}
+
+// iamRoleSummary summarizes an IAM role. It is publicly exposed if its trust policy lets anyone
+// assume it without conditions.
+func iamRoleSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	role := details.AwsIamRole
+	if role == nil {
+		return resourceSummary{}, false
+	}
+	summary := resourceSummary{Name: aws.ToString(role.RoleName)}
+	if public, ok := trustsAnyone(aws.ToString(role.AssumeRolePolicyDocument)); ok {
+		summary.Public = strconv.FormatBool(public)
+	}
+	return summary, true
+}
+
+// policyStatement is the part of an IAM policy statement needed to tell if it trusts anyone
+type policyStatement struct {
+	Effect    string          `json:"Effect"`
+	Principal json.RawMessage `json:"Principal"`
+	Condition json.RawMessage `json:"Condition"`
+}
+
+// trustsAnyone returns true if a URL-encoded trust policy allows any principal without
+// conditions, and false if the policy can't be parsed
+func trustsAnyone(document string) (bool, bool) {
+	if document == "" {
+		return false, false
+	}
+	if decoded, err := url.QueryUnescape(document); err == nil {
+		document = decoded
+	}
+	var policy struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return false, false
+	}
+	// a policy with a single statement may have an object rather than a list
+	var statements []policyStatement
+	if err := json.Unmarshal(policy.Statement, &statements); err != nil {
+		var statement policyStatement
+		if err := json.Unmarshal(policy.Statement, &statement); err != nil {
+			return false, false
+		}
+		statements = []policyStatement{statement}
+	}
+
+	for _, statement := range statements {
+		if statement.Effect == "Allow" && len(statement.Condition) == 0 && isAnyPrincipal(statement.Principal) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// isAnyPrincipal returns true if a policy principal is "*" or {"AWS": "*"}
+func isAnyPrincipal(principal json.RawMessage) bool {
+	var s string
+	if json.Unmarshal(principal, &s) == nil {
+		return s == "*"
+	}
+	var principals map[string]json.RawMessage
+	if json.Unmarshal(principal, &principals) != nil {
+		return false
+	}
+	var values []string
+	if json.Unmarshal(principals["AWS"], &s) == nil {
+		values = []string{s}
+	} else if json.Unmarshal(principals["AWS"], &values) != nil {
+		return false
+	}
+	return slices.Contains(values, "*")
+}
+
+// lambdaFunctionSummary summarizes a Lambda function by its runtime, or its package type for
+// container image functions
+func lambdaFunctionSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	function := details.AwsLambdaFunction
+	if function == nil {
+		return resourceSummary{}, false
+	}
+	image := aws.ToString(function.Runtime)
+	if image == "" {
+		image = aws.ToString(function.PackageType)
+	}
+	return resourceSummary{Name: aws.ToString(function.FunctionName), Image: image}, true
+}
+
+// ecrImageSummary summarizes an ECR image by its repository and first tag, or its digest if it
+// is untagged
+func ecrImageSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	image := details.AwsEcrContainerImage
+	if image == nil {
+		return resourceSummary{}, false
+	}
+	repository := aws.ToString(image.RepositoryName)
+	reference := repository + "@" + aws.ToString(image.ImageDigest)
+	if len(image.ImageTags) > 0 {
+		reference = repository + ":" + image.ImageTags[0]
+	}
+	return resourceSummary{Name: repository, Image: reference}, true
+}
+
+// rdsInstanceSummary summarizes an RDS instance by its engine and version
+func rdsInstanceSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	instance := details.AwsRdsDbInstance
+	if instance == nil {
+		return resourceSummary{}, false
+	}
+	summary := resourceSummary{
+		Name:  aws.ToString(instance.DBInstanceIdentifier),
+		Image: strings.TrimSpace(aws.ToString(instance.Engine) + " " + aws.ToString(instance.EngineVersion)),
+	}
+	if instance.PubliclyAccessible != nil {
+		summary.Public = strconv.FormatBool(*instance.PubliclyAccessible)
+	}
+	return summary, true
+}
+
+// ecsTaskDefinitionSummary summarizes an ECS task definition by the images of its containers
+func ecsTaskDefinitionSummary(details *types.ResourceDetails) (resourceSummary, bool) {
+	taskDefinition := details.AwsEcsTaskDefinition
+	if taskDefinition == nil {
+		return resourceSummary{}, false
+	}
+	var images []string
+	for _, container := range taskDefinition.ContainerDefinitions {
+		if image := aws.ToString(container.Image); image != "" {
+			images = append(images, image)
+		}
+	}
+	return resourceSummary{Name: aws.ToString(taskDefinition.Family), Image: strings.Join(images, ", ")}, true
+}
+
+// containsAny returns true if s contains any of the substrings
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package securityhubcollector
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResourceColumns(t *testing.T) {
+	publicTrustPolicy := url.QueryEscape(`{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":"sts:AssumeRole"}}`)
+	serviceTrustPolicy := url.QueryEscape(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)
+
+	testCases := []struct {
+		name     string
+		resource types.Resource
+		expected []string
+	}{
+		{
+			name: "EC2 instance",
+			resource: types.Resource{
+				Tags: map[string]string{"Name": "web-1", "owner": "alice"},
+				Details: &types.ResourceDetails{AwsEc2Instance: &types.AwsEc2InstanceDetails{
+					ImageId:       aws.String("ami-0123456789abcdef0"),
+					IpV4Addresses: []string{"10.0.0.12", "54.1.2.3"},
+				}},
+			},
+			expected: []string{"web-1", "alice", "ami-0123456789abcdef0", "true"},
+		},
+		{
+			name: "private EC2 instance",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsEc2Instance: &types.AwsEc2InstanceDetails{
+					ImageId:       aws.String("ami-0123456789abcdef0"),
+					IpV4Addresses: []string{"10.0.0.12"},
+				}},
+			},
+			expected: []string{"", "", "ami-0123456789abcdef0", "false"},
+		},
+		{
+			name: "public S3 bucket",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsS3Bucket: &types.AwsS3BucketDetails{
+					Name:              aws.String("website"),
+					AccessControlList: aws.String(`{"grantList":[{"grantee":{"URI":"http://acs.amazonaws.com/groups/global/AllUsers"},"permission":"READ"}]}`),
+				}},
+			},
+			expected: []string{"website", "", "", "true"},
+		},
+		{
+			name: "blocked S3 bucket",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsS3Bucket: &types.AwsS3BucketDetails{
+					Name: aws.String("data"),
+					PublicAccessBlockConfiguration: &types.AwsS3AccountPublicAccessBlockDetails{
+						BlockPublicAcls: aws.Bool(true), IgnorePublicAcls: aws.Bool(true), BlockPublicPolicy: aws.Bool(true), RestrictPublicBuckets: aws.Bool(true),
+					},
+				}},
+			},
+			expected: []string{"data", "", "", "false"},
+		},
+		{
+			name: "S3 bucket without public access block",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsS3Bucket: &types.AwsS3BucketDetails{Name: aws.String("logs")}},
+			},
+			expected: []string{"logs", "", "", ""},
+		},
+		{
+			name: "public IAM role",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsIamRole: &types.AwsIamRoleDetails{
+					RoleName:                 aws.String("open"),
+					AssumeRolePolicyDocument: aws.String(publicTrustPolicy),
+				}},
+			},
+			expected: []string{"open", "", "", "true"},
+		},
+		{
+			name: "service IAM role",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsIamRole: &types.AwsIamRoleDetails{
+					RoleName:                 aws.String("lambda-exec"),
+					AssumeRolePolicyDocument: aws.String(serviceTrustPolicy),
+				}},
+			},
+			expected: []string{"lambda-exec", "", "", "false"},
+		},
+		{
+			name: "Lambda function",
+			resource: types.Resource{
+				Tags: map[string]string{"Owner": "bob"},
+				Details: &types.ResourceDetails{AwsLambdaFunction: &types.AwsLambdaFunctionDetails{
+					FunctionName: aws.String("handler"),
+					Runtime:      aws.String("python3.12"),
+				}},
+			},
+			expected: []string{"handler", "bob", "python3.12", ""},
+		},
+		{
+			name: "ECR image",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsEcrContainerImage: &types.AwsEcrContainerImageDetails{
+					RepositoryName: aws.String("api"),
+					ImageDigest:    aws.String("sha256:abc"),
+					ImageTags:      []string{"v1.2.3", "latest"},
+				}},
+			},
+			expected: []string{"api", "", "api:v1.2.3", ""},
+		},
+		{
+			name: "untagged ECR image",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsEcrContainerImage: &types.AwsEcrContainerImageDetails{
+					RepositoryName: aws.String("api"),
+					ImageDigest:    aws.String("sha256:abc"),
+				}},
+			},
+			expected: []string{"api", "", "api@sha256:abc", ""},
+		},
+		{
+			name: "RDS instance",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsRdsDbInstance: &types.AwsRdsDbInstanceDetails{
+					DBInstanceIdentifier: aws.String("db-1"),
+					Engine:               aws.String("postgres"),
+					EngineVersion:        aws.String("15.4"),
+					PubliclyAccessible:   aws.Bool(false),
+				}},
+			},
+			expected: []string{"db-1", "", "postgres 15.4", "false"},
+		},
+		{
+			name: "ECS task definition",
+			resource: types.Resource{
+				Details: &types.ResourceDetails{AwsEcsTaskDefinition: &types.AwsEcsTaskDefinitionDetails{
+					Family: aws.String("worker"),
+					ContainerDefinitions: []types.AwsEcsTaskDefinitionContainerDefinitionsDetails{
+						{Image: aws.String("000000000001.dkr.ecr.us-east-1.amazonaws.com/worker:1")},
+						{Image: aws.String("public.ecr.aws/aws-observability/aws-otel-collector:latest")},
+					},
+				}},
+			},
+			expected: []string{"worker", "", "000000000001.dkr.ecr.us-east-1.amazonaws.com/worker:1, public.ecr.aws/aws-observability/aws-otel-collector:latest", ""},
+		},
+		{
+			name:     "other resource",
+			resource: types.Resource{Tags: map[string]string{"Name": "vpc-main", "team-owner": "carol"}},
+			expected: []string{"vpc-main", "", "", ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var record FindingRecord
+			h := HubCollector{}
+			h.setResourceColumns(&record, tc.resource)
+			actual := []string{record.ResourceName, record.ResourceOwner, record.ResourceImage, record.PubliclyExposed}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: resource columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+
+	// a custom owner tag key
+	var record FindingRecord
+	h := HubCollector{OwnerTagKey: "team-owner"}
+	h.setResourceColumns(&record, types.Resource{Tags: map[string]string{"Owner": "bob", "team-owner": "carol"}})
+	if record.ResourceOwner != "carol" {
+		t.Errorf("ERROR: expected owner carol from the team-owner tag, got %q", record.ResourceOwner)
+	}
+}
+
+func TestResourceColumnHeaders(t *testing.T) {
+	columns := []string{"Resource Name", "Resource Owner", "Resource Image", "Publicly Exposed"}
+
+	h := HubCollector{}
+	for _, header := range h.headers() {
+		if slices.Contains(columns, header) {
+			t.Errorf("ERROR: expected no resource columns by default, got %s", header)
+		}
+	}
+
+	h = HubCollector{ResourceColumns: true}
+	headers := h.headers()
+	for _, column := range columns {
+		if !slices.Contains(headers, column) {
+			t.Errorf("ERROR: expected a %s column with resource columns, got headers %v", column, headers)
+		}
+	}
+}
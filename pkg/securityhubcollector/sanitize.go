@@ -0,0 +1,146 @@
+package securityhubcollector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Sanitization modes
+const (
+	// SanitizeASCII replaces every rune outside printable ASCII with a space, which is how the
+	// Collector has always cleaned up fields for QuickSight
+	SanitizeASCII = "ascii"
+	// SanitizeUnicode keeps printable Unicode and only replaces control characters and the
+	// delimiter
+	SanitizeUnicode = "unicode"
+)
+
+// SanitizeModes lists the supported sanitization modes
+var SanitizeModes = []string{SanitizeASCII, SanitizeUnicode}
+
+// Newline handling in SanitizeUnicode mode
+const (
+	// NewlinesSpace replaces newlines with spaces
+	NewlinesSpace = "space"
+	// NewlinesEscape writes newlines as the two characters \n, so that Markdown in remediation
+	// text can be restored
+	NewlinesEscape = "escape"
+	// NewlinesKeep keeps newlines, for formats that encode them safely
+	NewlinesKeep = "keep"
+)
+
+// NewlineModes lists the supported newline handling modes
+var NewlineModes = []string{NewlinesSpace, NewlinesEscape, NewlinesKeep}
+
+// SanitizePolicy describes how field values are cleaned up before they are written
+type SanitizePolicy struct {
+	// Mode is one of SanitizeModes. The other settings only apply to SanitizeUnicode.
+	Mode string
+	// Delimiter is replaced with a space when it is set
+	Delimiter rune
+	// Newlines is one of NewlineModes
+	Newlines string
+	// Transliterate replaces accented letters and typographic punctuation, like smart quotes and
+	// dashes, with their closest ASCII equivalents. Other characters are kept.
+	Transliterate bool
+}
+
+// DefaultSanitizePolicy returns the sanitization policy for an output format: Unicode is kept,
+// the delimiter of delimited formats is replaced, and newlines are kept only in JSONL, where
+// they are escaped by the encoding
+func DefaultSanitizePolicy(format string) SanitizePolicy {
+	switch format {
+	case FormatCSV:
+		return SanitizePolicy{Mode: SanitizeUnicode, Delimiter: ',', Newlines: NewlinesSpace}
+	case FormatJSONL:
+		return SanitizePolicy{Mode: SanitizeUnicode, Newlines: NewlinesKeep}
+	default:
+		return SanitizePolicy{Mode: SanitizeUnicode, Delimiter: '\t', Newlines: NewlinesSpace}
+	}
+}
+
+// Validate checks the policy's mode and newline handling
+func (p SanitizePolicy) Validate() error {
+	switch {
+	case !slices.Contains(SanitizeModes, p.Mode):
+		return fmt.Errorf("unknown sanitization mode %q; expected one of %s", p.Mode, strings.Join(SanitizeModes, ", "))
+	case p.Mode == SanitizeUnicode && !slices.Contains(NewlineModes, p.Newlines):
+		return fmt.Errorf("unknown newline handling %q; expected one of %s", p.Newlines, strings.Join(NewlineModes, ", "))
+	}
+	return nil
+}
+
+// sanitizeASCII replaces everything outside printable ASCII, which might break CSV parsing in
+// QuickSight, with spaces, as the Collector has always done
+func sanitizeASCII(field string) string {
+	var builder strings.Builder
+	builder.Grow(len(field))
+	for _, r := range field {
+		if r >= 32 && r <= 126 {
+			builder.WriteRune(r) // Keep printable ASCII
+		} else {
+			builder.WriteRune(' ') // Everything else becomes space
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// Apply returns the sanitized field, with leading and trailing whitespace removed
+func (p SanitizePolicy) Apply(field string) string {
+	if p.Mode == SanitizeASCII {
+		return sanitizeASCII(field)
+	}
+
+	field = strings.TrimSpace(strings.ReplaceAll(field, "\r\n", "\n"))
+	if p.Transliterate {
+		field = transliterate(field)
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(field))
+	for _, r := range field {
+		switch {
+		case r == '\n' || r == '\u2028' || r == '\u2029':
+			switch p.Newlines {
+			case NewlinesEscape:
+				builder.WriteString(`\n`)
+			case NewlinesKeep:
+				builder.WriteRune('\n')
+			default:
+				builder.WriteRune(' ')
+			}
+		case r == p.Delimiter && p.Delimiter != 0,
+			unicode.IsControl(r),
+			r == unicode.ReplacementChar:
+			builder.WriteRune(' ')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// asciiReplacements maps typographic punctuation to ASCII
+var asciiReplacements = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "−", "-",
+	"…", "...", "•", "*", "\u00a0", " ", "\u202f", " ", "«", "<<", "»", ">>",
+	"ß", "ss", "æ", "ae", "Æ", "AE", "œ", "oe", "Œ", "OE", "ø", "o", "Ø", "O",
+)
+
+// transliterate replaces accented letters with unaccented ones and typographic punctuation with ASCII
+func transliterate(field string) string {
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(stripMarks, field)
+	if err != nil {
+		result = field
+	}
+	return asciiReplacements.Replace(result)
+}
@@ -0,0 +1,83 @@
+package securityhubcollector
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+)
+
+func TestSanitizePolicy(t *testing.T) {
+	const field = "  Café “quoted” – naïve\r\n- step 1\n- step 2\twith tab, comma\x00 日本語  "
+
+	testCases := []struct {
+		name     string
+		policy   SanitizePolicy
+		expected string
+	}{
+		{
+			name:     "ascii",
+			policy:   SanitizePolicy{Mode: SanitizeASCII},
+			expected: "Caf   quoted    na ve  - step 1 - step 2 with tab, comma",
+		},
+		{
+			name:     "tsv default",
+			policy:   DefaultSanitizePolicy(FormatTSV),
+			expected: "Café “quoted” – naïve - step 1 - step 2 with tab, comma  日本語",
+		},
+		{
+			name:     "csv default",
+			policy:   DefaultSanitizePolicy(FormatCSV),
+			expected: "Café “quoted” – naïve - step 1 - step 2 with tab  comma  日本語",
+		},
+		{
+			name:     "jsonl default",
+			policy:   DefaultSanitizePolicy(FormatJSONL),
+			expected: "Café “quoted” – naïve\n- step 1\n- step 2 with tab, comma  日本語",
+		},
+		{
+			name:     "escaped newlines",
+			policy:   SanitizePolicy{Mode: SanitizeUnicode, Delimiter: '\t', Newlines: NewlinesEscape},
+			expected: `Café “quoted” – naïve\n- step 1\n- step 2 with tab, comma  日本語`,
+		},
+		{
+			name:     "transliterated",
+			policy:   SanitizePolicy{Mode: SanitizeUnicode, Delimiter: '\t', Newlines: NewlinesSpace, Transliterate: true},
+			expected: `Cafe "quoted" - naive - step 1 - step 2 with tab, comma  日本語`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); err != nil {
+				t.Fatalf("ERROR: invalid policy: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, tc.policy.Apply(field)); diff != "" {
+				t.Errorf("ERROR: sanitized field mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSanitizePolicyValidate(t *testing.T) {
+	for _, policy := range []SanitizePolicy{
+		{Mode: "latin1"},
+		{Mode: SanitizeUnicode, Newlines: "drop"},
+	} {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("ERROR: expected an error validating %+v", policy)
+		}
+	}
+}
+
+func TestAlteredValues(t *testing.T) {
+	h := HubCollector{Summary: summary.NewBuilder()}
+	h.row(FindingRecord{ID: "f1", Title: "  Title with trailing newline\n", Description: "Line 1\nLine 2"})
+	h.row(FindingRecord{ID: "f2", Title: "Tab\tin title", Description: "Plain"})
+
+	expected := []summary.Count{{Key: "Description", Rows: 1, Findings: 1}, {Key: "Title", Rows: 1, Findings: 1}}
+	if diff := cmp.Diff(expected, h.Summary.Summary().AlteredValues); diff != "" {
+		t.Errorf("ERROR: altered values mismatch (-expected +actual):\n%s", diff)
+	}
+}
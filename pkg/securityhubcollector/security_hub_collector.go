@@ -3,60 +3,152 @@ package securityhubcollector
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/securityhub"
 	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
 
 	"github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/catalog"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/feeds"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/helpers"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/metrics"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/severity"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/sla"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/suppression"
 	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/tracing"
 
-	"encoding/csv"
 	"os"
 
 	"github.com/benbjohnson/clock"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Values for the Team Source column, which records where a row's team came from
+const (
+	TeamSourceAccountMap  = "Account Map"
+	TeamSourceResourceTag = "Resource Tag"
 )
 
 // HubCollector is a generic struct used to hold setting info
 type HubCollector struct {
-	outputFile *os.File
-	csvWriter  *csv.Writer
-}
+	// TeamTagKey is an optional resource tag key (e.g. cms:team). When a finding's resource
+	// carries this tag, its value is used as the team instead of the account's team. When it is
+	// set, rows have a Team Source column saying which one was used.
+	TeamTagKey string
+	// KnownTeams is an optional list of valid team names. When set, tag values that don't
+	// match one of these names are ignored and the account's team is used.
+	KnownTeams []string
+	// TeamMapSourceColumn adds a Team Map Source column naming the team source each row's account
+	// mapping came from.
+	TeamMapSourceColumn bool
+
+	// ResourceColumns adds the Resource Name, Resource Owner, Resource Image and Publicly Exposed
+	// columns.
+	ResourceColumns bool
+	// OwnerTagKey is the resource tag key whose value is written to the Resource Owner column. It
+	// defaults to DefaultOwnerTagKey.
+	OwnerTagKey string
+
+	// Format is the output file format, one of Formats. It defaults to FormatTSV.
+	Format string
+
+	// RecordStates are the record states of the findings to collect. It defaults to ACTIVE.
+	RecordStates []string
+	// ExcludedWorkflowStatuses are the workflow statuses of findings to leave out. It defaults
+	// to RESOLVED.
+	ExcludedWorkflowStatuses []string
+
+	// ObservedColumns adds the First Observed At and Last Observed At columns.
+	ObservedColumns bool
+
+	// RunID identifies the collection run. When it is set, it is written to the optional Run ID
+	// column, linking every row to the run's manifest.
+	RunID string
+
+	// Sanitize is the policy for cleaning up field values before they are written. It defaults
+	// to DefaultSanitizePolicy(Format).
+	Sanitize *SanitizePolicy
+
+	// DedupKey is the key, one of DedupKeys, that rows are deduplicated by. When it is set, rows
+	// are held back until WriteDeduplicated is called, and only the most recently updated row for
+	// each key is written, with optional Duplicate Count and Duplicate Regions columns.
+	DedupKey string
+
+	// ComplianceColumns adds the Control ID, Associated Standards and Related Requirements columns.
+	ComplianceColumns bool
+	// Catalog optionally maps control IDs to the organization's control catalog. When it is set,
+	// rows have Catalog Controls and Catalog Families columns.
+	Catalog *catalog.Catalog
+
+	// VulnerabilityColumns adds the CVE IDs, CVSS Score, Vulnerable Packages, Fixed In Versions
+	// and Exploit Available columns.
+	VulnerabilityColumns bool
+	// EPSS is an optional EPSS feed. When it is set, rows have an EPSS Score column, whose scores
+	// from the feed take precedence over the ones products report.
+	EPSS feeds.EPSS
+	// KEV is the optional CISA Known Exploited Vulnerabilities catalog. When it is set, rows
+	// have a Known Exploited column.
+	KEV feeds.KEV
+
+	// SeverityOverrides are optional rules that remap the severity of rows. When they are set,
+	// rows have Effective Severity and Severity Rule columns, and SLAs are computed from the
+	// effective severity.
+	SeverityOverrides *severity.Overrides
+
+	// SLA is an optional remediation SLA policy. When it is set, rows have Age Days, SLA Due Date,
+	// SLA Days Remaining and SLA Overdue columns.
+	SLA *sla.Policy
+
+	// Suppressions are optional accepted risk rules. When they are set, rows have Risk Status and
+	// Suppression Rule columns; rows matching a rule are tagged or left out, depending on the
+	// rule's action.
+	Suppressions *suppression.Rules
+
+	// Clock is used for the Date Collected and SLA columns, and to expire suppression rules. It defaults to the system clock.
+	Clock clock.Clock
+
+	// Redaction is an optional redaction profile. When it is set, every row is also written,
+	// redacted, to RedactedOutputFileName, for sharing outside the program.
+	Redaction              *RedactionProfile
+	RedactedOutputFileName string
+
+	// ClientOptions are applied when loading the SDK config for the Security Hub and STS
+	// clients, e.g. to instrument API calls
+	ClientOptions []func(*config.LoadOptions) error
+
+	// Metrics optionally counts the rows written
+	Metrics *metrics.Metrics
+
+	// Logger is used to log the progress of every GetFindingsAndWriteToOutput call. It defaults
+	// to slog.Default().
+	Logger *slog.Logger
+
+	// Summary optionally accumulates the rows written and the outcome of every
+	// GetFindingsAndWriteToOutput call into a run summary
+	Summary *summary.Builder
 
-// convert all control characters that might break CSV parsing in QuickSight to spaces
-func sanitizeFieldForCSV(field string) string {
-	var builder strings.Builder
-	builder.Grow(len(field))
-	for _, r := range field {
-		if r >= 32 && r <= 126 {
-			builder.WriteRune(r) // Keep printable ASCII
-		} else {
-			builder.WriteRune(' ') // Everything else becomes space
-		}
-	}
-	return strings.TrimSpace(builder.String())
-}
-
-func standardizeTimestamp(timestamp string) string {
-	// this is the custom time format that includes outliers we've seen in Security Hub findings
-	//  - time zone
-	//  - microseconds
-	// we'll need to update this if we notice that Quicksight is skipping a lot of rows
-	// due to MALFORMED_DATE errors when it ingests the data
+	outputFile *os.File
+	writer     rowWriter
+	dedup      *deduplicator
 
-	t, err := time.Parse("2006-01-02T15:04:05.999999Z07:00", timestamp)
-	if err != nil {
-		log.Printf("could not standardize the date string %s", timestamp)
-		return timestamp
-	}
+	// columns and columnHeaders are the indexes and headers of the columns written, computed
+	// once by Initialize
+	columns       []int
+	columnHeaders []string
 
-	// standardize to a Quicksight-friendly format
-	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+	redactor       *redactor
+	redactedFile   *os.File
+	redactedWriter rowWriter
 }
 
 // Initialize sets up the HubCollector object and writes the header row to the output file.
@@ -64,43 +156,93 @@ func (h *HubCollector) Initialize(outputFileName string) error {
 	if h.isInitialized() {
 		return fmt.Errorf("HubCollector is already initialized")
 	}
+	err := h.sanitizePolicy().Validate()
+	if err != nil {
+		return err
+	}
+	if h.DedupKey != "" {
+		dedup, err := newDeduplicator(h.DedupKey)
+		if err != nil {
+			return err
+		}
+		h.dedup = dedup
+	}
+	h.reportExpiredRules()
+	h.columns = h.columnIndexes()
+	h.columnHeaders = h.headers()
 
-	// create the output file and CSV writer
+	// create the output file and the writer for the output format
 	f, err := os.Create(filepath.Clean(outputFileName))
 	if err != nil {
 		return fmt.Errorf("could not create output file: %v", err)
 	}
+	writer, err := newRowWriter(f, h.Format)
+	if err != nil {
+		return helpers.CombineErrors(err, f.Close())
+	}
 	h.outputFile = f
-	h.csvWriter = csv.NewWriter(h.outputFile)
-	// use tab delimiters since we were seeing some INCORRECT_FIELD_COUNT
-	// errors on QuickSight ingestion due to unescaped commas in some fields
-	h.csvWriter.Comma = '\t'
+	h.writer = writer
 
 	err = h.writeHeadersToOutput()
 	if err != nil {
 		return fmt.Errorf("could not write headers to output file: %v", err)
 	}
 
+	if h.Redaction != nil {
+		err = h.initializeRedactedOutput()
+		if err != nil {
+			return helpers.CombineErrors(err, h.outputFile.Close())
+		}
+	}
+
+	return nil
+}
+
+// initializeRedactedOutput creates the redacted output file and writes its header row
+func (h *HubCollector) initializeRedactedOutput() error {
+	r, err := newRedactor(*h.Redaction)
+	if err != nil {
+		return err
+	}
+	if h.RedactedOutputFileName == "" {
+		return fmt.Errorf("redaction requires a redacted output file")
+	}
+
+	f, err := os.Create(filepath.Clean(h.RedactedOutputFileName))
+	if err != nil {
+		return fmt.Errorf("could not create redacted output file: %v", err)
+	}
+	writer, err := newRowWriter(f, h.Format)
+	if err != nil {
+		return helpers.CombineErrors(err, f.Close())
+	}
+	err = writer.WriteHeader(h.headers())
+	if err != nil {
+		return helpers.CombineErrors(fmt.Errorf("could not write headers to redacted output file: %v", err), f.Close())
+	}
+
+	h.redactor = r
+	h.redactedFile = f
+	h.redactedWriter = writer
 	return nil
 }
 
 // isInitialized checks if the HubCollector has the required properties to perform file IO
 func (h *HubCollector) isInitialized() bool {
-	return h.outputFile != nil && h.csvWriter != nil
+	return h.outputFile != nil && h.writer != nil
 }
 
-// FlushAndClose flushes the CSV writer and closes the output file
+// FlushAndClose flushes the output writer and closes the output file
 func (h *HubCollector) FlushAndClose() error {
 	if !h.isInitialized() {
 		return fmt.Errorf("HubCollector is not initialized")
 	}
 
-	h.csvWriter.Flush()
-	err := h.csvWriter.Error()
+	err := h.writer.Flush()
 	if err != nil {
-		return fmt.Errorf("could not flush CSV writer: %v", err)
+		return fmt.Errorf("could not flush output writer: %v", err)
 	}
-	h.csvWriter = nil
+	h.writer = nil
 
 	err = h.outputFile.Close()
 	if err != nil {
@@ -108,71 +250,199 @@ func (h *HubCollector) FlushAndClose() error {
 	}
 	h.outputFile = nil
 
+	if h.redactedFile != nil {
+		err = helpers.CombineErrors(h.redactedWriter.Flush(), h.redactedFile.Close())
+		if err != nil {
+			return fmt.Errorf("could not close redacted output file: %v", err)
+		}
+		h.redactedWriter = nil
+		h.redactedFile = nil
+	}
+
 	return nil
 }
 
 // GetFindingsAndWriteToOutput - gets all security hub findings from a single AWS account and writes them to the output file
-func (h *HubCollector) GetFindingsAndWriteToOutput(secHubRegion, teamName string, account teams.Account) error {
-	// We want all the security findings that are active and not resolved.
+func (h *HubCollector) GetFindingsAndWriteToOutput(ctx context.Context, secHubRegion, teamName string, account teams.Account) (err error) {
+	job := summary.Job{Team: teamName, AccountID: account.ID, Region: secHubRegion}
+	logger := h.logger().With("team", teamName, "account_id", account.ID, "region", secHubRegion)
+	logger.Info("getting findings")
+	ctx, span := tracing.Start(ctx, "get findings",
+		tracing.AttrTeam.String(teamName), tracing.AttrAccountID.String(account.ID), tracing.AttrRegion.String(secHubRegion))
+	start := time.Now()
+	defer func() {
+		job.Duration = time.Since(start)
+		if err != nil {
+			job.Error = err.Error()
+			logger.Error("could not get findings", "error", err, "error_class", client.ErrorClass(err), "page", job.APICalls, "duration_ms", job.Duration.Milliseconds())
+		} else {
+			logger.Info("got findings", "rows", job.Rows, "pages", job.APICalls, "duration_ms", job.Duration.Milliseconds())
+		}
+		span.SetAttributes(tracing.AttrPage.Int(job.APICalls), tracing.AttrFindings.Int(job.Findings), tracing.AttrRows.Int(job.Rows))
+		tracing.End(span, err)
+		if h.Summary != nil {
+			h.Summary.AddJob(job)
+		}
+	}()
+
 	params := &securityhub.GetFindingsInput{
-		Filters: &types.AwsSecurityFindingFilters{
-			RecordState: []types.StringFilter{
-				{
-					Comparison: types.StringFilterComparisonEquals,
-					Value:      aws.String(string(types.RecordStateActive)),
-				},
-			},
-			WorkflowStatus: []types.StringFilter{
-				{
-					Comparison: types.StringFilterComparisonNotEquals,
-					Value:      aws.String(string(types.WorkflowStatusResolved)),
-				},
-			},
-		},
+		Filters:    h.findingFilters(),
 		MaxResults: aws.Int32(100),
 	}
 
-	securityHubClient, err := client.MakeSecurityHubClient(secHubRegion, account.RoleARN)
+	securityHubClient, err := h.makeSecurityHubClient(ctx, secHubRegion, account)
 	if err != nil {
-		return fmt.Errorf("could not make security hub client: %s", err)
+		return fmt.Errorf("could not make security hub client: %w", err)
 	}
 	paginator := securityhub.NewGetFindingsPaginator(securityHubClient, params)
 
 	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(context.TODO())
+		job.APICalls++
+		pageStart := time.Now()
+		page, err := h.nextPage(ctx, paginator, job.APICalls)
 		if err != nil {
-			return fmt.Errorf("could not get next page of findings: %s", err)
+			return fmt.Errorf("could not get next page of findings: %w", err)
 		}
-		err = h.writeFindingsToOutput(page.Findings, teamName, account.Environment)
+		job.Findings += len(page.Findings)
+		rows, err := h.writePage(ctx, page.Findings, teamName, account)
+		job.Rows += rows
 		if err != nil {
-			return fmt.Errorf("could not write findings to output: %s", err)
+			return fmt.Errorf("could not write findings to output: %w", err)
 		}
+		logger.Debug("got page of findings", "page", job.APICalls, "rows", rows, "duration_ms", time.Since(pageStart).Milliseconds())
 	}
 
 	return nil
 }
 
+// makeSecurityHubClient makes a Security Hub client for the account. The account's role, if
+// any, is assumed up front rather than on the first GetFindings call, so that role assumption
+// is traced on its own.
+func (h *HubCollector) makeSecurityHubClient(ctx context.Context, secHubRegion string, account teams.Account) (_ *securityhub.Client, err error) {
+	var creds aws.CredentialsProvider
+	if account.RoleARN != "" {
+		ctx, span := tracing.Start(ctx, "assume role",
+			tracing.AttrAccountID.String(account.ID), tracing.AttrRegion.String(secHubRegion), attribute.String("role_arn", account.RoleARN))
+		creds, err = client.MakeAssumeRoleProvider(secHubRegion, account.RoleARN, account.ExternalID, h.ClientOptions...)
+		if err == nil {
+			_, err = creds.Retrieve(ctx)
+		}
+		tracing.End(span, err)
+		if err != nil {
+			return nil, fmt.Errorf("could not assume role %s: %w", account.RoleARN, err)
+		}
+	}
+	return client.MakeSecurityHubClientWithCredentials(secHubRegion, creds, h.ClientOptions...)
+}
+
+// nextPage gets the next page of findings
+func (h *HubCollector) nextPage(ctx context.Context, paginator *securityhub.GetFindingsPaginator, pageNumber int) (*securityhub.GetFindingsOutput, error) {
+	ctx, span := tracing.Start(ctx, "get findings page", tracing.AttrPage.Int(pageNumber))
+	page, err := paginator.NextPage(ctx)
+	if err == nil {
+		span.SetAttributes(tracing.AttrFindings.Int(len(page.Findings)))
+	}
+	tracing.End(span, err)
+	return page, err
+}
+
+// writePage writes a page of findings to the output file and returns the number of rows written
+func (h *HubCollector) writePage(ctx context.Context, findings []types.AwsSecurityFinding, teamName string, account teams.Account) (int, error) {
+	_, span := tracing.Start(ctx, "write findings", tracing.AttrFindings.Int(len(findings)))
+	rows, err := h.writeFindingsToOutput(findings, teamName, account)
+	span.SetAttributes(tracing.AttrRows.Int(rows))
+	tracing.End(span, err)
+	return rows, err
+}
+
+// logger returns the Logger, or the default logger if it is not set
+func (h *HubCollector) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
+}
+
+// findingFilters returns the GetFindings filters for the configured record states and excluded
+// workflow statuses. By default, we want all the security findings that are active and not resolved.
+func (h *HubCollector) findingFilters() *types.AwsSecurityFindingFilters {
+	recordStates := h.RecordStates
+	if len(recordStates) == 0 {
+		recordStates = []string{string(types.RecordStateActive)}
+	}
+	excludedStatuses := h.ExcludedWorkflowStatuses
+	if len(excludedStatuses) == 0 {
+		excludedStatuses = []string{string(types.WorkflowStatusResolved)}
+	}
+
+	filters := &types.AwsSecurityFindingFilters{}
+	for _, state := range recordStates {
+		filters.RecordState = append(filters.RecordState, types.StringFilter{
+			Comparison: types.StringFilterComparisonEquals,
+			Value:      aws.String(state),
+		})
+	}
+	for _, status := range excludedStatuses {
+		filters.WorkflowStatus = append(filters.WorkflowStatus, types.StringFilter{
+			Comparison: types.StringFilterComparisonNotEquals,
+			Value:      aws.String(status),
+		})
+	}
+	return filters
+}
+
 type FindingRecord struct {
-	Team             string `csv:"Team"`
-	ResourceType     string `csv:"Resource Type"`
-	ID               string `csv:"ID"`
-	ProductARN       string `csv:"Product ARN"`
-	Title            string `csv:"Title"`
-	Description      string `csv:"Description"`
-	SeverityLabel    string `csv:"Severity Label"`
-	RemediationText  string `csv:"Remediation Text"`
-	RemediationURL   string `csv:"Remediation URL"`
-	ResourceID       string `csv:"Resource ID"`
-	AWSAccountID     string `csv:"AWS Account ID"`
-	ComplianceStatus string `csv:"Compliance Status"`
-	RecordState      string `csv:"Record State"`
-	WorkflowStatus   string `csv:"Workflow Status"`
-	CreatedAt        string `csv:"Created At"`
-	UpdatedAt        string `csv:"Updated At"`
-	Region           string `csv:"Region"`
-	Environment      string `csv:"Environment"`
-	Product          string `csv:"Product"`
-	DateCollected    string `csv:"Date Collected"`
+	Team                string `csv:"Team"`
+	ResourceType        string `csv:"Resource Type"`
+	ID                  string `csv:"ID"`
+	ProductARN          string `csv:"Product ARN"`
+	Title               string `csv:"Title"`
+	Description         string `csv:"Description"`
+	SeverityLabel       string `csv:"Severity Label"`
+	RemediationText     string `csv:"Remediation Text"`
+	RemediationURL      string `csv:"Remediation URL"`
+	ResourceID          string `csv:"Resource ID"`
+	AWSAccountID        string `csv:"AWS Account ID"`
+	ComplianceStatus    string `csv:"Compliance Status"`
+	RecordState         string `csv:"Record State"`
+	WorkflowStatus      string `csv:"Workflow Status"`
+	CreatedAt           string `csv:"Created At"`
+	UpdatedAt           string `csv:"Updated At"`
+	Region              string `csv:"Region"`
+	Environment         string `csv:"Environment"`
+	Product             string `csv:"Product"`
+	DateCollected       string `csv:"Date Collected"`
+	TeamSource          string `csv:"Team Source" optional:"team-tag"`
+	TeamMapSource       string `csv:"Team Map Source" optional:"team-map-source"`
+	ControlID           string `csv:"Control ID" optional:"compliance"`
+	FirstObservedAt     string `csv:"First Observed At" optional:"observed"`
+	LastObservedAt      string `csv:"Last Observed At" optional:"observed"`
+	AssociatedStandards string `csv:"Associated Standards" optional:"compliance"`
+	RelatedRequirements string `csv:"Related Requirements" optional:"compliance"`
+	ResourceName        string `csv:"Resource Name" optional:"resources"`
+	ResourceOwner       string `csv:"Resource Owner" optional:"resources"`
+	ResourceImage       string `csv:"Resource Image" optional:"resources"`
+	PubliclyExposed     string `csv:"Publicly Exposed" optional:"resources"`
+	CVEIDs              string `csv:"CVE IDs" optional:"vulnerabilities"`
+	CVSSScore           string `csv:"CVSS Score" optional:"vulnerabilities"`
+	VulnerablePackages  string `csv:"Vulnerable Packages" optional:"vulnerabilities"`
+	FixedInVersions     string `csv:"Fixed In Versions" optional:"vulnerabilities"`
+	ExploitAvailable    string `csv:"Exploit Available" optional:"vulnerabilities"`
+	EPSSScore           string `csv:"EPSS Score" optional:"epss"`
+	RunID               string `csv:"Run ID" optional:"run-id"`
+	DuplicateCount      string `csv:"Duplicate Count" optional:"dedup"`
+	DuplicateRegions    string `csv:"Duplicate Regions" optional:"dedup"`
+	EffectiveSeverity   string `csv:"Effective Severity" optional:"severity"`
+	SeverityRule        string `csv:"Severity Rule" optional:"severity"`
+	AgeDays             string `csv:"Age Days" optional:"sla"`
+	SLADueDate          string `csv:"SLA Due Date" optional:"sla"`
+	SLADaysRemaining    string `csv:"SLA Days Remaining" optional:"sla"`
+	SLAOverdue          string `csv:"SLA Overdue" optional:"sla"`
+	RiskStatus          string `csv:"Risk Status" optional:"suppression"`
+	SuppressionRule     string `csv:"Suppression Rule" optional:"suppression"`
+	CatalogControls     string `csv:"Catalog Controls" optional:"catalog"`
+	CatalogFamilies     string `csv:"Catalog Families" optional:"catalog"`
+	KnownExploited      string `csv:"Known Exploited" optional:"kev"`
 }
 
 // GetHeaders returns a slice of header names from the CSV tags of the struct fields.
@@ -182,33 +452,169 @@ func (FindingRecord) GetHeaders() []string {
 	headers := make([]string, t.NumField())
 
 	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if csvHeader := field.Tag.Get("csv"); csvHeader != "" {
-			headers[i] = csvHeader
-		} else {
-			headers[i] = field.Name
-		}
+		headers[i] = recordHeader(t.Field(i))
 	}
 
 	return headers
 }
 
-func (r FindingRecord) ToSanitizedSlice() []string {
+// recordHeader returns the header for a FindingRecord field: its CSV tag, or its name if it has none
+func recordHeader(field reflect.StructField) string {
+	if csvHeader := field.Tag.Get("csv"); csvHeader != "" {
+		return csvHeader
+	}
+	return field.Name
+}
+
+// columnIndexes returns the indexes of the FindingRecord fields the HubCollector writes: every
+// field except optional ones (tagged optional:"<name>") that aren't enabled
+func (h *HubCollector) columnIndexes() []int {
+	if h.columns != nil {
+		return h.columns
+	}
+	t := reflect.TypeOf(FindingRecord{})
+	indexes := make([]int, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if optional := t.Field(i).Tag.Get("optional"); optional != "" && !h.optionalColumnEnabled(optional) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// optionalColumnEnabled returns true if the optional column with the given name is written
+func (h *HubCollector) optionalColumnEnabled(name string) bool {
+	switch name {
+	case "team-tag":
+		return h.TeamTagKey != ""
+	case "team-map-source":
+		return h.TeamMapSourceColumn
+	case "observed":
+		return h.ObservedColumns
+	case "run-id":
+		return h.RunID != ""
+	case "dedup":
+		return h.DedupKey != ""
+	case "compliance":
+		return h.ComplianceColumns
+	case "catalog":
+		return h.Catalog != nil
+	case "resources":
+		return h.ResourceColumns
+	case "vulnerabilities":
+		return h.VulnerabilityColumns
+	case "epss":
+		return h.EPSS != nil
+	case "kev":
+		return h.KEV != nil
+	case "severity":
+		return h.SeverityOverrides != nil
+	case "sla":
+		return h.SLA != nil
+	case "suppression":
+		return h.Suppressions != nil
+	default:
+		return false
+	}
+}
+
+// headers returns the headers of the columns the HubCollector writes
+func (h *HubCollector) headers() []string {
+	if h.columnHeaders != nil {
+		return h.columnHeaders
+	}
+	return selectColumns(FindingRecord{}.GetHeaders(), h.columnIndexes())
+}
+
+// row returns the sanitized values of the columns the HubCollector writes for a record. Values
+// that sanitization alters, other than by trimming whitespace, are counted in the Summary by column.
+func (h *HubCollector) row(record FindingRecord) []string {
+	return h.sanitizedRow(record, h.Summary)
+}
+
+// sanitizedRow returns the sanitized values of the columns the HubCollector writes for a record,
+// counting altered values in counts if it is set
+func (h *HubCollector) sanitizedRow(record FindingRecord, counts *summary.Builder) []string {
+	policy := h.sanitizePolicy()
+	headers := h.headers()
+	values := selectColumns(record.values(), h.columnIndexes())
+	for i, value := range values {
+		values[i] = policy.Apply(value)
+		if counts != nil && values[i] != strings.TrimSpace(value) {
+			counts.AddAlteredValue(headers[i], record.ID)
+		}
+	}
+	return values
+}
+
+// sanitizePolicy returns the Sanitize policy, or the default policy for the output format
+func (h *HubCollector) sanitizePolicy() SanitizePolicy {
+	if h.Sanitize != nil {
+		return *h.Sanitize
+	}
+	return DefaultSanitizePolicy(h.Format)
+}
+
+// selectColumns returns the values at the given indexes
+func selectColumns(values []string, indexes []int) []string {
+	selected := make([]string, len(indexes))
+	for i, index := range indexes {
+		selected[i] = values[index]
+	}
+	return selected
+}
+
+// values returns the values of every field
+func (r FindingRecord) values() []string {
 	v := reflect.ValueOf(r)
 	slice := make([]string, v.NumField())
 
 	for i := 0; i < v.NumField(); i++ {
-		fieldValue := v.Field(i)
-		// Since all fields in FindingRecord are strings, we can safely convert and sanitize
-		slice[i] = sanitizeFieldForCSV(fieldValue.String())
+		// all fields in FindingRecord are strings
+		slice[i] = v.Field(i).String()
 	}
 
 	return slice
 }
 
-// convertFindingToRows - converts a single finding to the record format we're using
-func (h *HubCollector) convertFindingToRows(finding types.AwsSecurityFinding, teamName, environment string, clock clock.Clock) [][]string {
+// SuppressionRow returns the fields of the record that suppression rules match on
+func (r FindingRecord) SuppressionRow() suppression.Row {
+	return suppression.Row{
+		AccountID:   r.AWSAccountID,
+		Team:        r.Team,
+		ControlID:   r.ControlID,
+		ResourceARN: r.ResourceID,
+		Product:     r.Product,
+		Title:       r.Title,
+	}
+}
+
+// SummaryRow returns the fields of the record that are counted in a run summary
+func (r FindingRecord) SummaryRow() summary.Row {
+	return summary.Row{
+		FindingID:        r.ID,
+		Team:             r.Team,
+		AccountID:        r.AWSAccountID,
+		Region:           r.Region,
+		SeverityLabel:    r.SeverityLabel,
+		ComplianceStatus: r.ComplianceStatus,
+		Product:          r.Product,
+	}
+}
+
+// convertFindingToRows - converts a single finding to the sanitized rows we write to the output file
+func (h *HubCollector) convertFindingToRows(finding types.AwsSecurityFinding, teamName string, account teams.Account, clock clock.Clock) [][]string {
 	var output [][]string
+	for _, record := range h.convertFindingToRecords(finding, teamName, account, clock) {
+		output = append(output, h.row(record))
+	}
+	return output
+}
+
+// convertFindingToRecords - converts a single finding to the record format we're using, one record per resource
+func (h *HubCollector) convertFindingToRecords(finding types.AwsSecurityFinding, teamName string, account teams.Account, clock clock.Clock) []FindingRecord {
+	var output []FindingRecord
 
 	for _, r := range finding.Resources {
 		region := aws.ToString(r.Region)
@@ -216,24 +622,34 @@ func (h *HubCollector) convertFindingToRows(finding types.AwsSecurityFinding, te
 			region = aws.ToString(finding.Region)
 		}
 
+		team, teamSource := h.resolveTeam(r, teamName)
+
 		record := FindingRecord{
-			Team:          teamName,
-			ResourceType:  aws.ToString(r.Type),
-			ID:            aws.ToString(finding.Id),
-			ProductARN:    aws.ToString(finding.ProductArn),
-			Title:         aws.ToString(finding.Title),
-			Description:   aws.ToString(finding.Description),
-			ResourceID:    aws.ToString(r.Id),
-			AWSAccountID:  aws.ToString(finding.AwsAccountId),
-			RecordState:   string(finding.RecordState),
-			CreatedAt:     standardizeTimestamp(aws.ToString(finding.CreatedAt)),
-			UpdatedAt:     standardizeTimestamp(aws.ToString(finding.UpdatedAt)),
-			Region:        region,
-			Environment:   environment,
-			Product:       aws.ToString(finding.ProductName),
-			DateCollected: clock.Now().Format("01-02-2006"),
+			Team:            team,
+			ResourceType:    aws.ToString(r.Type),
+			ID:              aws.ToString(finding.Id),
+			ProductARN:      aws.ToString(finding.ProductArn),
+			Title:           aws.ToString(finding.Title),
+			Description:     aws.ToString(finding.Description),
+			ResourceID:      aws.ToString(r.Id),
+			AWSAccountID:    aws.ToString(finding.AwsAccountId),
+			RecordState:     string(finding.RecordState),
+			CreatedAt:       h.timestamp(finding.CreatedAt, finding),
+			UpdatedAt:       h.timestamp(finding.UpdatedAt, finding),
+			FirstObservedAt: h.timestamp(finding.FirstObservedAt, finding),
+			LastObservedAt:  h.timestamp(finding.LastObservedAt, finding),
+			Region:          region,
+			Environment:     account.Environment,
+			Product:         aws.ToString(finding.ProductName),
+			DateCollected:   clock.Now().Format("01-02-2006"),
+			TeamSource:      teamSource,
+			TeamMapSource:   account.Source,
+			RunID:           h.RunID,
 		}
 
+		h.setResourceColumns(&record, r)
+		h.setVulnerabilityColumns(&record, finding.Vulnerabilities)
+
 		// Handle optional pointer fields with inline nil checks
 		if finding.Severity != nil {
 			record.SeverityLabel = string(finding.Severity.Label)
@@ -246,42 +662,235 @@ func (h *HubCollector) convertFindingToRows(finding types.AwsSecurityFinding, te
 
 		if finding.Compliance != nil {
 			record.ComplianceStatus = string(finding.Compliance.Status)
+			record.ControlID = aws.ToString(finding.Compliance.SecurityControlId)
+			record.AssociatedStandards = associatedStandards(finding.Compliance)
+			record.RelatedRequirements = strings.Join(finding.Compliance.RelatedRequirements, ", ")
 		}
 
+		controls, families := h.Catalog.Lookup(record.ControlID)
+		record.CatalogControls = strings.Join(controls, ", ")
+		record.CatalogFamilies = strings.Join(families, ", ")
+
 		if finding.Workflow != nil {
 			record.WorkflowStatus = string(finding.Workflow.Status)
 		}
 
-		output = append(output, record.ToSanitizedSlice())
+		h.setSeverityColumns(&record)
+		h.setSLAColumns(&record, clock.Now())
+
+		output = append(output, record)
 	}
 
 	return output
 }
 
+// associatedStandards returns the IDs of the standards a finding's control is enabled in, e.g.
+// standards/aws-foundational-security-best-practices/v/1.0.0, joined with commas
+func associatedStandards(compliance *types.Compliance) string {
+	standards := make([]string, 0, len(compliance.AssociatedStandards))
+	for _, standard := range compliance.AssociatedStandards {
+		if id := aws.ToString(standard.StandardsId); id != "" {
+			standards = append(standards, id)
+		}
+	}
+	return strings.Join(standards, ", ")
+}
+
+// timestamp normalizes a timestamp of a finding to TimestampLayout. Timestamps that can't be
+// parsed are left empty, since QuickSight drops rows with malformed dates, and counted in the
+// Summary by product.
+func (h *HubCollector) timestamp(timestamp *string, finding types.AwsSecurityFinding) string {
+	normalized, ok := normalizeTimestamp(aws.ToString(timestamp))
+	if !ok && h.Summary != nil {
+		h.Summary.AddUnparseableTimestamp(aws.ToString(finding.ProductName), aws.ToString(finding.Id))
+	}
+	return normalized
+}
+
+// setSeverityColumns sets the Effective Severity and Severity Rule columns of a record from the
+// severity overrides, if there are any
+func (h *HubCollector) setSeverityColumns(record *FindingRecord) {
+	if h.SeverityOverrides == nil {
+		return
+	}
+	record.EffectiveSeverity, record.SeverityRule = h.SeverityOverrides.Apply(severity.Row{
+		Product:     record.Product,
+		ControlID:   record.ControlID,
+		Environment: record.Environment,
+		Team:        record.Team,
+		Severity:    record.SeverityLabel,
+	})
+}
+
+// setSLAColumns sets the SLA columns of a record from the SLA policy, if there is one. Findings
+// are aged from when they were first observed, or created if that isn't known. The columns are
+// left empty if neither timestamp is known, and all but Age Days are empty for severities
+// without an SLA.
+func (h *HubCollector) setSLAColumns(record *FindingRecord, now time.Time) {
+	if h.SLA == nil {
+		return
+	}
+	observed := record.FirstObservedAt
+	if observed == "" {
+		observed = record.CreatedAt
+	}
+	start, err := time.Parse(TimestampLayout, observed)
+	if err != nil {
+		return
+	}
+
+	severityLabel := record.SeverityLabel
+	if h.SeverityOverrides != nil {
+		severityLabel = record.EffectiveSeverity
+	}
+	status := h.SLA.Evaluate(severityLabel, record.Team, record.Environment, start, now)
+	record.AgeDays = strconv.Itoa(status.AgeDays)
+	if status.HasSLA {
+		record.SLADueDate = status.Due.Format("01-02-2006")
+		record.SLADaysRemaining = strconv.Itoa(status.DaysRemaining)
+		record.SLAOverdue = strconv.FormatBool(status.Overdue)
+	}
+}
+
+// clock returns the Clock, or the system clock if it is not set
+func (h *HubCollector) clock() clock.Clock {
+	if h.Clock != nil {
+		return h.Clock
+	}
+	return clock.New()
+}
+
+// resolveTeam returns the team for a resource and where it came from. If a team tag key is
+// configured and the resource carries that tag, the tag value takes precedence over the
+// account's team, unless KnownTeams is set and the value isn't one of them.
+func (h *HubCollector) resolveTeam(r types.Resource, accountTeam string) (string, string) {
+	if h.TeamTagKey == "" {
+		return accountTeam, TeamSourceAccountMap
+	}
+
+	tagValue := strings.TrimSpace(r.Tags[h.TeamTagKey])
+	if tagValue == "" {
+		return accountTeam, TeamSourceAccountMap
+	}
+
+	if len(h.KnownTeams) == 0 {
+		return tagValue, TeamSourceResourceTag
+	}
+
+	// match known team names case-insensitively, but report the canonical name
+	for _, known := range h.KnownTeams {
+		if strings.EqualFold(known, tagValue) {
+			return known, TeamSourceResourceTag
+		}
+	}
+
+	return accountTeam, TeamSourceAccountMap
+}
+
 // writeHeadersToOutput - writes headers to the output CSV file
 func (h *HubCollector) writeHeadersToOutput() error {
 	if !h.isInitialized() {
 		return fmt.Errorf("HubCollector is not initialized")
 	}
-	return h.csvWriter.Write(FindingRecord{}.GetHeaders())
+	return h.writer.WriteHeader(h.headers())
 }
 
 // writeFindingsToOutput - takes a list of security findings and writes them to the output file.
-func (h *HubCollector) writeFindingsToOutput(findings []types.AwsSecurityFinding, teamName, environment string) error {
+// It returns the number of rows written, or held back for deduplication.
+func (h *HubCollector) writeFindingsToOutput(findings []types.AwsSecurityFinding, teamName string, account teams.Account) (int, error) {
 	if !h.isInitialized() {
-		return fmt.Errorf("HubCollector is not initialized")
+		return 0, fmt.Errorf("HubCollector is not initialized")
 	}
 
+	rows := 0
+	clock := h.clock()
 	for _, finding := range findings {
-		clock := clock.New()
-		records := h.convertFindingToRows(finding, teamName, environment, clock)
+		records := h.convertFindingToRecords(finding, teamName, account, clock)
 		for _, record := range records {
-			err := h.csvWriter.Write(record)
-			if err != nil {
-				return err
+			if h.suppress(&record, clock.Now()) {
+				continue
 			}
+			if h.dedup != nil {
+				h.dedup.add(finding, record)
+			} else if err := h.writeRecord(record); err != nil {
+				return rows, err
+			}
+			rows++
 		}
 	}
 
+	return rows, nil
+}
+
+// suppress applies the first suppression rule that matches a record, tagging the record with the
+// rule's ID, and returns true if the rule excludes it from the output
+func (h *HubCollector) suppress(record *FindingRecord, now time.Time) bool {
+	rule := h.Suppressions.Match(record.SuppressionRow(), now)
+	if rule == nil {
+		return false
+	}
+	excluded := rule.Action == suppression.ActionExclude
+	if h.Summary != nil {
+		h.Summary.AddSuppressed(rule.ID, record.ID, excluded)
+	}
+	record.RiskStatus = suppression.StatusAcceptedRisk
+	record.SuppressionRule = rule.ID
+	return excluded
+}
+
+// reportExpiredRules logs the suppression rules that have expired and records them in the Summary,
+// so they can be renewed or removed
+func (h *HubCollector) reportExpiredRules() {
+	for _, rule := range h.Suppressions.Expired(h.clock().Now()) {
+		h.logger().Warn("suppression rule has expired", "rule_id", rule.ID, "owner", rule.Owner, "ticket", rule.Ticket, "expires", rule.Expires)
+		if h.Summary != nil {
+			h.Summary.AddExpiredRule(summary.ExpiredRule{ID: rule.ID, Owner: rule.Owner, Ticket: rule.Ticket, Expires: rule.Expires})
+		}
+	}
+}
+
+// writeRecord writes a row to the output file and counts it
+func (h *HubCollector) writeRecord(record FindingRecord) error {
+	err := h.writer.Write(h.row(record))
+	if err != nil {
+		return err
+	}
+	if h.redactor != nil {
+		// altered values are only counted once, for the full output
+		err = h.redactedWriter.Write(h.sanitizedRow(h.redactor.record(record), nil))
+		if err != nil {
+			return fmt.Errorf("could not write redacted row: %w", err)
+		}
+	}
+	h.Metrics.FindingCollected(record.Team, record.SeverityLabel)
+	if h.Summary != nil {
+		h.Summary.Add(record.SummaryRow())
+	}
+	return nil
+}
+
+// WriteDeduplicated writes the rows held back for deduplication to the output file. It must be
+// called once all findings are collected, before FlushAndClose, and does nothing when DedupKey
+// isn't set.
+func (h *HubCollector) WriteDeduplicated() error {
+	if h.dedup == nil {
+		return nil
+	}
+	if !h.isInitialized() {
+		return fmt.Errorf("HubCollector is not initialized")
+	}
+
+	records, removed := h.dedup.records()
+	for _, record := range records {
+		err := h.writeRecord(record)
+		if err != nil {
+			return fmt.Errorf("could not write findings to output: %v", err)
+		}
+	}
+	if h.Summary != nil {
+		h.Summary.AddDuplicates(removed)
+	}
+	h.logger().Info("removed duplicate rows", "dedup_key", h.DedupKey, "rows", len(records), "duplicates", removed)
+	h.dedup, _ = newDeduplicator(h.DedupKey)
 	return nil
 }
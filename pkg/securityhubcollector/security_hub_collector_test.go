@@ -2,6 +2,8 @@ package securityhubcollector
 
 import (
 	"log"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -9,6 +11,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
 	"github.com/benbjohnson/clock"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/catalog"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/severity"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/sla"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/suppression"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
 )
 
 func mustParseTime(s string) time.Time {
@@ -23,8 +33,12 @@ type testCase struct {
 	name        string
 	teamName    string
 	environment string
-	finding     types.AwsSecurityFinding
-	expected    [][]string
+	teamTagKey  string
+	knownTeams  []string
+	// teamMapSourceColumn adds the Team Map Source column
+	teamMapSourceColumn bool
+	finding             types.AwsSecurityFinding
+	expected            [][]string
 }
 
 // This function tests the conversion of a security finding into the
@@ -61,7 +75,15 @@ func TestConvertFindingToRows(t *testing.T) {
 						Url:  aws.String("https://example.com/dothething"),
 					},
 				},
-				Compliance: &types.Compliance{Status: types.ComplianceStatusFailed},
+				Compliance: &types.Compliance{
+					Status:            types.ComplianceStatusFailed,
+					SecurityControlId: aws.String("EC2.6"),
+					AssociatedStandards: []types.AssociatedStandard{
+						{StandardsId: aws.String("standards/aws-foundational-security-best-practices/v/1.0.0")},
+						{StandardsId: aws.String("standards/nist-800-53/v/5.0.0")},
+					},
+					RelatedRequirements: []string{"NIST.800-53.r5 AC-4", "NIST.800-53.r5 SC-7"},
+				},
 			},
 			expected: [][]string{
 				{
@@ -289,19 +311,572 @@ func TestConvertFindingToRows(t *testing.T) {
 				},
 			},
 		},
-	}
 
-	h := HubCollector{}
+		{
+			name:        "Team tag overrides account team",
+			teamName:    "Test Team 1",
+			environment: "dev",
+			teamTagKey:  "cms:team",
+			finding: types.AwsSecurityFinding{
+				Id:           aws.String("testID5"),
+				AwsAccountId: aws.String("000000000001"),
+				CreatedAt:    aws.String("2020-03-22T13:22:13.933Z"),
+				Description:  aws.String("Tagged Test Finding"),
+				ProductArn:   aws.String("arn:aws:securityhub:us-east-1::product/aws/securityhub"),
+				ProductName:  aws.String("Security Hub"),
+				RecordState:  types.RecordStateActive,
+				Resources: []types.Resource{
+					{
+						Id:     aws.String("arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000001"),
+						Type:   aws.String("AwsEc2Vpc"),
+						Region: aws.String("us-east-1"),
+						Tags:   map[string]string{"cms:team": "Test Team 2"},
+					},
+					{
+						Id:     aws.String("arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000002"),
+						Type:   aws.String("AwsEc2Vpc"),
+						Region: aws.String("us-east-1"),
+					},
+				},
+				SchemaVersion: aws.String("2018-10-08"),
+				Title:         aws.String("Tagged Test Finding Title"),
+				UpdatedAt:     aws.String("2020-03-22T13:22:13.933Z"),
+				Workflow:      &types.Workflow{Status: types.WorkflowStatusNew},
+				Severity:      &types.Severity{Label: types.SeverityLabelLow},
+				Compliance:    &types.Compliance{Status: types.ComplianceStatusFailed},
+			},
+			expected: [][]string{
+				{
+					"Test Team 2",
+					"AwsEc2Vpc",
+					"testID5",
+					"arn:aws:securityhub:us-east-1::product/aws/securityhub",
+					"Tagged Test Finding Title",
+					"Tagged Test Finding",
+					"LOW",
+					"",
+					"",
+					"arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000001",
+					"000000000001",
+					"FAILED",
+					"ACTIVE",
+					"NEW",
+					"2020-03-22T13:22:13.933Z",
+					"2020-03-22T13:22:13.933Z",
+					"us-east-1",
+					"dev",
+					"Security Hub",
+					"01-01-2023",
+					"Resource Tag",
+				},
+				{
+					"Test Team 1",
+					"AwsEc2Vpc",
+					"testID5",
+					"arn:aws:securityhub:us-east-1::product/aws/securityhub",
+					"Tagged Test Finding Title",
+					"Tagged Test Finding",
+					"LOW",
+					"",
+					"",
+					"arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000002",
+					"000000000001",
+					"FAILED",
+					"ACTIVE",
+					"NEW",
+					"2020-03-22T13:22:13.933Z",
+					"2020-03-22T13:22:13.933Z",
+					"us-east-1",
+					"dev",
+					"Security Hub",
+					"01-01-2023",
+					"Account Map",
+				},
+			},
+		},
+
+		{
+			name:        "Team tag validated against known teams",
+			teamName:    "Test Team 1",
+			environment: "dev",
+			teamTagKey:  "cms:team",
+			knownTeams:  []string{"Test Team 1", "Test Team 2"},
+			finding: types.AwsSecurityFinding{
+				Id:           aws.String("testID6"),
+				AwsAccountId: aws.String("000000000001"),
+				CreatedAt:    aws.String("2020-03-22T13:22:13.933Z"),
+				Description:  aws.String("Tagged Test Finding"),
+				ProductArn:   aws.String("arn:aws:securityhub:us-east-1::product/aws/securityhub"),
+				ProductName:  aws.String("Security Hub"),
+				RecordState:  types.RecordStateActive,
+				Resources: []types.Resource{
+					{
+						Id:     aws.String("arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000001"),
+						Type:   aws.String("AwsEc2Vpc"),
+						Region: aws.String("us-east-1"),
+						Tags:   map[string]string{"cms:team": "test team 2"},
+					},
+					{
+						Id:     aws.String("arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000002"),
+						Type:   aws.String("AwsEc2Vpc"),
+						Region: aws.String("us-east-1"),
+						Tags:   map[string]string{"cms:team": "Unknown Team"},
+					},
+				},
+				SchemaVersion: aws.String("2018-10-08"),
+				Title:         aws.String("Tagged Test Finding Title"),
+				UpdatedAt:     aws.String("2020-03-22T13:22:13.933Z"),
+				Workflow:      &types.Workflow{Status: types.WorkflowStatusNew},
+				Severity:      &types.Severity{Label: types.SeverityLabelLow},
+				Compliance:    &types.Compliance{Status: types.ComplianceStatusFailed},
+			},
+			expected: [][]string{
+				{
+					"Test Team 2",
+					"AwsEc2Vpc",
+					"testID6",
+					"arn:aws:securityhub:us-east-1::product/aws/securityhub",
+					"Tagged Test Finding Title",
+					"Tagged Test Finding",
+					"LOW",
+					"",
+					"",
+					"arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000001",
+					"000000000001",
+					"FAILED",
+					"ACTIVE",
+					"NEW",
+					"2020-03-22T13:22:13.933Z",
+					"2020-03-22T13:22:13.933Z",
+					"us-east-1",
+					"dev",
+					"Security Hub",
+					"01-01-2023",
+					"Resource Tag",
+				},
+				{
+					"Test Team 1",
+					"AwsEc2Vpc",
+					"testID6",
+					"arn:aws:securityhub:us-east-1::product/aws/securityhub",
+					"Tagged Test Finding Title",
+					"Tagged Test Finding",
+					"LOW",
+					"",
+					"",
+					"arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000002",
+					"000000000001",
+					"FAILED",
+					"ACTIVE",
+					"NEW",
+					"2020-03-22T13:22:13.933Z",
+					"2020-03-22T13:22:13.933Z",
+					"us-east-1",
+					"dev",
+					"Security Hub",
+					"01-01-2023",
+					"Account Map",
+				},
+			},
+		},
+
+		{
+			name:                "Team map source column",
+			teamName:            "Test Team 1",
+			environment:         "dev",
+			teamMapSourceColumn: true,
+			finding: types.AwsSecurityFinding{
+				Id:          aws.String("testID7"),
+				CreatedAt:   aws.String("2020-03-22T13:22:13.933Z"),
+				UpdatedAt:   aws.String("2020-03-22T13:22:13.933Z"),
+				ProductName: aws.String("Security Hub"),
+				RecordState: types.RecordStateActive,
+				Resources: []types.Resource{
+					{
+						Id:     aws.String("arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000001"),
+						Type:   aws.String("AwsEc2Vpc"),
+						Region: aws.String("us-east-1"),
+					},
+				},
+			},
+			expected: [][]string{
+				{
+					"Test Team 1",
+					"AwsEc2Vpc",
+					"testID7",
+					"",
+					"",
+					"",
+					"",
+					"",
+					"",
+					"arn:aws:ec2:us-test-1:000000000001:vpc/vpc-00000000000000001",
+					"",
+					"",
+					"ACTIVE",
+					"",
+					"2020-03-22T13:22:13.933Z",
+					"2020-03-22T13:22:13.933Z",
+					"us-east-1",
+					"dev",
+					"Security Hub",
+					"01-01-2023",
+					"teams-api",
+				},
+			},
+		},
+	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockClock := clock.NewMock()
 			mockClock.Set(mustParseTime("2023-01-01"))
 
-			actual := h.convertFindingToRows(tc.finding, tc.teamName, tc.environment, mockClock)
+			h := HubCollector{TeamTagKey: tc.teamTagKey, KnownTeams: tc.knownTeams, TeamMapSourceColumn: tc.teamMapSourceColumn}
+			account := teams.Account{ID: "000000000001", Environment: tc.environment, Source: "teams-api"}
+			actual := h.convertFindingToRows(tc.finding, tc.teamName, account, mockClock)
 			if diff := cmp.Diff(tc.expected, actual); diff != "" {
 				t.Fatalf("Expected rows did not match actual: %s", diff)
 			}
 		})
 	}
 }
+
+func TestFindingFilters(t *testing.T) {
+	stringFilter := func(comparison types.StringFilterComparison, value string) types.StringFilter {
+		return types.StringFilter{Comparison: comparison, Value: aws.String(value)}
+	}
+
+	testCases := []struct {
+		name     string
+		h        HubCollector
+		expected *types.AwsSecurityFindingFilters
+	}{
+		{
+			name: "defaults to active and not resolved",
+			h:    HubCollector{},
+			expected: &types.AwsSecurityFindingFilters{
+				RecordState:    []types.StringFilter{stringFilter(types.StringFilterComparisonEquals, "ACTIVE")},
+				WorkflowStatus: []types.StringFilter{stringFilter(types.StringFilterComparisonNotEquals, "RESOLVED")},
+			},
+		},
+		{
+			name: "configured states and statuses",
+			h:    HubCollector{RecordStates: []string{"ACTIVE", "ARCHIVED"}, ExcludedWorkflowStatuses: []string{"RESOLVED", "SUPPRESSED"}},
+			expected: &types.AwsSecurityFindingFilters{
+				RecordState: []types.StringFilter{
+					stringFilter(types.StringFilterComparisonEquals, "ACTIVE"),
+					stringFilter(types.StringFilterComparisonEquals, "ARCHIVED"),
+				},
+				WorkflowStatus: []types.StringFilter{
+					stringFilter(types.StringFilterComparisonNotEquals, "RESOLVED"),
+					stringFilter(types.StringFilterComparisonNotEquals, "SUPPRESSED"),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.h.findingFilters()
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.IgnoreUnexported(types.AwsSecurityFindingFilters{}, types.StringFilter{})); diff != "" {
+				t.Fatalf("Expected filters did not match actual: %s", diff)
+			}
+		})
+	}
+}
+
+// columnFinding returns a finding with a single resource, for tests of individual columns
+func columnFinding() types.AwsSecurityFinding {
+	return types.AwsSecurityFinding{
+		Id:        aws.String("testID"),
+		Resources: []types.Resource{{Id: aws.String("resource"), Region: aws.String("us-east-1")}},
+	}
+}
+
+// columnValues converts finding to rows with h and returns the values of the given columns in
+// the first row, keyed by header. Columns that h doesn't write are left out.
+func columnValues(t *testing.T, h HubCollector, finding types.AwsSecurityFinding, account teams.Account, clock clock.Clock, columns ...string) map[string]string {
+	t.Helper()
+	headers := h.headers()
+	rows := h.convertFindingToRows(finding, "Test Team", account, clock)
+	if len(rows) == 0 {
+		t.Fatal("ERROR: expected at least one row")
+	}
+	if len(rows[0]) != len(headers) {
+		t.Fatalf("ERROR: expected %d columns, got %d", len(headers), len(rows[0]))
+	}
+	values := make(map[string]string)
+	for _, column := range columns {
+		if i := slices.Index(headers, column); i >= 0 {
+			values[column] = rows[0][i]
+		}
+	}
+	return values
+}
+
+func TestRunIDColumn(t *testing.T) {
+	testCases := []struct {
+		name     string
+		h        HubCollector
+		expected map[string]string
+	}{
+		{
+			name:     "no run ID",
+			h:        HubCollector{},
+			expected: map[string]string{},
+		},
+		{
+			name:     "run ID",
+			h:        HubCollector{RunID: "run-1"},
+			expected: map[string]string{"Run ID": "run-1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := columnValues(t, tc.h, columnFinding(), teams.Account{ID: "000000000001"}, clock.NewMock(), "Run ID")
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: run ID column mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSLAColumns(t *testing.T) {
+	finding := columnFinding()
+	finding.CreatedAt = aws.String("2023-01-03T00:00:00.000Z")
+	finding.FirstObservedAt = aws.String("2023-01-01T12:00:00.000Z")
+	finding.Severity = &types.Severity{Label: types.SeverityLabelHigh}
+	account := teams.Account{ID: "000000000001", Environment: "prod"}
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2023, 2, 5, 8, 0, 0, 0, time.UTC))
+	columns := []string{"Age Days", "SLA Due Date", "SLA Days Remaining", "SLA Overdue"}
+
+	testCases := []struct {
+		name     string
+		policy   *sla.Policy
+		finding  func(types.AwsSecurityFinding) types.AwsSecurityFinding
+		expected map[string]string
+	}{
+		{
+			name:     "no SLA policy",
+			expected: map[string]string{},
+		},
+		{
+			name:     "overdue in prod",
+			policy:   &sla.Policy{Days: map[string]int{"HIGH": 60}, Overrides: []sla.Override{{Environment: "prod", Days: map[string]int{"HIGH": 30}}}},
+			expected: map[string]string{"Age Days": "35", "SLA Due Date": "01-31-2023", "SLA Days Remaining": "-5", "SLA Overdue": "true"},
+		},
+		{
+			name:     "within SLA",
+			policy:   &sla.Policy{Days: map[string]int{"HIGH": 60}},
+			expected: map[string]string{"Age Days": "35", "SLA Due Date": "03-02-2023", "SLA Days Remaining": "25", "SLA Overdue": "false"},
+		},
+		{
+			name:     "no SLA for severity",
+			policy:   &sla.Policy{Days: map[string]int{"CRITICAL": 15}},
+			expected: map[string]string{"Age Days": "35", "SLA Due Date": "", "SLA Days Remaining": "", "SLA Overdue": ""},
+		},
+		{
+			name:   "aged from creation",
+			policy: &sla.Policy{Days: map[string]int{"HIGH": 30}},
+			finding: func(f types.AwsSecurityFinding) types.AwsSecurityFinding {
+				f.FirstObservedAt = nil
+				return f
+			},
+			expected: map[string]string{"Age Days": "33", "SLA Due Date": "02-02-2023", "SLA Days Remaining": "-3", "SLA Overdue": "true"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := finding
+			if tc.finding != nil {
+				f = tc.finding(f)
+			}
+			actual := columnValues(t, HubCollector{SLA: tc.policy}, f, account, mockClock, columns...)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: SLA columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSuppressionRules(t *testing.T) {
+	// each case parses its own rules, so changes to them don't leak into the next case
+	parseRules := func(t *testing.T) *suppression.Rules {
+		t.Helper()
+		rules, err := suppression.ParseRulesFile("../suppression/suppression_rules_test_valid.json")
+		if err != nil {
+			t.Fatalf("ERROR: could not parse suppression rules: %s", err)
+		}
+		return rules
+	}
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	t.Run("exclude", func(t *testing.T) {
+		finding := func(id, controlID, resourceID string) types.AwsSecurityFinding {
+			return types.AwsSecurityFinding{
+				Id:           aws.String(id),
+				AwsAccountId: aws.String("000000000001"),
+				ProductName:  aws.String("Security Hub"),
+				Title:        aws.String(id),
+				Compliance:   &types.Compliance{Status: types.ComplianceStatusFailed, SecurityControlId: aws.String(controlID)},
+				Resources:    []types.Resource{{Id: aws.String(resourceID), Region: aws.String("us-east-1")}},
+			}
+		}
+		findings := []types.AwsSecurityFinding{
+			finding("logs", "S3.2", "arn:aws:s3:::access-logs"),
+			finding("data", "S3.2", "arn:aws:s3:::data"),
+		}
+
+		h := HubCollector{Suppressions: parseRules(t), Clock: mockClock, Summary: summary.NewBuilder()}
+		if err := h.Initialize(filepath.Join(t.TempDir(), "findings.tsv")); err != nil {
+			t.Fatalf("ERROR: could not initialize HubCollector: %s", err)
+		}
+		rows, err := h.writeFindingsToOutput(findings, "Test Team 1", teams.Account{ID: "000000000001"})
+		if err != nil || rows != 1 {
+			t.Errorf("ERROR: expected 1 row written, got %d (%v)", rows, err)
+		}
+		if err := h.FlushAndClose(); err != nil {
+			t.Fatalf("ERROR: could not close output file: %s", err)
+		}
+
+		s := h.Summary.Summary()
+		if s.Rows != 1 || s.ExcludedRows != 1 {
+			t.Errorf("ERROR: expected 1 row and 1 excluded row in the summary, got %d and %d", s.Rows, s.ExcludedRows)
+		}
+		expectedExpired := []summary.ExpiredRule{{ID: "AR-002", Owner: "Test Team 2", Ticket: "SEC-102", Expires: "2023-03-31"}}
+		if diff := cmp.Diff(expectedExpired, s.ExpiredRules); diff != "" {
+			t.Errorf("ERROR: expired rules mismatch (-expected +actual):\n%s", diff)
+		}
+	})
+
+	// rules with the tag action keep the row, with its risk status and rule ID
+	t.Run("tag", func(t *testing.T) {
+		rules := parseRules(t)
+		rules.Rules[0].Action = suppression.ActionTag
+		h := HubCollector{Suppressions: rules}
+		record := FindingRecord{ID: "f1", AWSAccountID: "000000000001", Team: "Test Team 1", ControlID: "S3.2", ResourceID: "arn:aws:s3:::access-logs"}
+		if h.suppress(&record, mockClock.Now()) {
+			t.Error("ERROR: expected a tagged row to be kept")
+		}
+		if record.RiskStatus != suppression.StatusAcceptedRisk || record.SuppressionRule != "AR-001" {
+			t.Errorf("ERROR: expected the row to be tagged by AR-001, got %q and %q", record.RiskStatus, record.SuppressionRule)
+		}
+	})
+}
+
+func TestSeverityOverrides(t *testing.T) {
+	finding := columnFinding()
+	finding.ProductName = aws.String("Inspector")
+	finding.CreatedAt = aws.String("2023-01-01T00:00:00.000Z")
+	finding.Severity = &types.Severity{Label: types.SeverityLabelHigh}
+	overrides := &severity.Overrides{Rules: []severity.Rule{
+		{ID: "SEV-001", Severity: "LOW", Products: []string{"Inspector"}, Environments: []string{"dev"}},
+	}}
+	policy := &sla.Policy{Days: map[string]int{"HIGH": 30, "LOW": 180}}
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2023, 2, 5, 0, 0, 0, 0, time.UTC))
+	columns := []string{"Severity Label", "Effective Severity", "Severity Rule", "SLA Due Date"}
+
+	testCases := []struct {
+		environment string
+		expected    map[string]string
+	}{
+		{
+			environment: "dev",
+			expected:    map[string]string{"Severity Label": "HIGH", "Effective Severity": "LOW", "Severity Rule": "SEV-001", "SLA Due Date": "06-30-2023"},
+		},
+		{
+			environment: "prod",
+			expected:    map[string]string{"Severity Label": "HIGH", "Effective Severity": "HIGH", "Severity Rule": "", "SLA Due Date": "01-31-2023"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.environment, func(t *testing.T) {
+			h := HubCollector{SeverityOverrides: overrides, SLA: policy}
+			actual := columnValues(t, h, finding, teams.Account{ID: "000000000001", Environment: tc.environment}, mockClock, columns...)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: severity columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCatalogColumns(t *testing.T) {
+	finding := columnFinding()
+	finding.Compliance = &types.Compliance{Status: types.ComplianceStatusFailed, SecurityControlId: aws.String("IAM.6")}
+	columns := []string{"Catalog Controls", "Catalog Families"}
+
+	testCases := []struct {
+		name     string
+		h        HubCollector
+		expected map[string]string
+	}{
+		{
+			name:     "no catalog",
+			h:        HubCollector{},
+			expected: map[string]string{},
+		},
+		{
+			name: "catalog",
+			h: HubCollector{Catalog: &catalog.Catalog{Controls: map[string][]catalog.Control{
+				"IAM.6": {{ID: "AC-2(1)", Family: "AC"}, {ID: "IA-2(1)", Family: "IA"}, {ID: "IA-2(2)", Family: "IA"}},
+			}}},
+			expected: map[string]string{"Catalog Controls": "AC-2(1), IA-2(1), IA-2(2)", "Catalog Families": "AC, IA"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := columnValues(t, tc.h, finding, teams.Account{ID: "000000000001"}, clock.NewMock(), columns...)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: catalog columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestComplianceColumns(t *testing.T) {
+	finding := columnFinding()
+	finding.Compliance = &types.Compliance{
+		Status:            types.ComplianceStatusFailed,
+		SecurityControlId: aws.String("EC2.6"),
+		AssociatedStandards: []types.AssociatedStandard{
+			{StandardsId: aws.String("standards/aws-foundational-security-best-practices/v/1.0.0")},
+			{StandardsId: aws.String("standards/nist-800-53/v/5.0.0")},
+		},
+		RelatedRequirements: []string{"NIST.800-53.r5 AC-4", "NIST.800-53.r5 SC-7"},
+	}
+	columns := []string{"Control ID", "Associated Standards", "Related Requirements"}
+
+	testCases := []struct {
+		name     string
+		h        HubCollector
+		expected map[string]string
+	}{
+		{
+			name:     "default",
+			h:        HubCollector{},
+			expected: map[string]string{},
+		},
+		{
+			name: "compliance columns",
+			h:    HubCollector{ComplianceColumns: true},
+			expected: map[string]string{
+				"Control ID":           "EC2.6",
+				"Associated Standards": "standards/aws-foundational-security-best-practices/v/1.0.0, standards/nist-800-53/v/5.0.0",
+				"Related Requirements": "NIST.800-53.r5 AC-4, NIST.800-53.r5 SC-7",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := columnValues(t, tc.h, finding, teams.Account{ID: "000000000001"}, clock.NewMock(), columns...)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: compliance columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package securityhubcollector
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampLayout is the layout timestamps are written in, which QuickSight parses as a date
+const TimestampLayout = "2006-01-02T15:04:05.000Z"
+
+// timestampLayouts are the ISO 8601 and RFC 3339 variants that Security Hub products have been
+// seen to emit. Fractional seconds of any precision are accepted by every layout, and timestamps
+// without a zone are taken to be UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// normalizeTimestamp converts a timestamp to TimestampLayout in UTC. Besides the layouts in
+// timestampLayouts, it accepts Unix epoch times in seconds or milliseconds. It returns false if
+// the timestamp can't be parsed; empty timestamps are left empty.
+func normalizeTimestamp(timestamp string) (string, bool) {
+	timestamp = strings.TrimSpace(timestamp)
+	if timestamp == "" {
+		return "", true
+	}
+
+	if t, ok := parseEpoch(timestamp); ok {
+		return t.UTC().Format(TimestampLayout), true
+	}
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, timestamp)
+		if err == nil {
+			return t.UTC().Format(TimestampLayout), true
+		}
+	}
+	return "", false
+}
+
+// parseEpoch parses a Unix epoch time in seconds (10 digits) or milliseconds (13 digits). Other
+// strings of digits, like basic ISO 8601 dates (20261019) or years, aren't taken to be epochs.
+func parseEpoch(timestamp string) (time.Time, bool) {
+	if len(timestamp) != 10 && len(timestamp) != 13 {
+		return time.Time{}, false
+	}
+	for _, r := range timestamp {
+		if r < '0' || r > '9' {
+			return time.Time{}, false
+		}
+	}
+	n, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if len(timestamp) == 10 {
+		return time.Unix(n, 0), true
+	}
+	return time.UnixMilli(n), true
+}
@@ -0,0 +1,98 @@
+package securityhubcollector
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/benbjohnson/clock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/summary"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+func TestNormalizeTimestamp(t *testing.T) {
+	testCases := []struct {
+		timestamp string
+		expected  string
+		ok        bool
+	}{
+		{"2020-03-22T13:22:13.933Z", "2020-03-22T13:22:13.933Z", true},
+		{"2020-03-22T13:22:13Z", "2020-03-22T13:22:13.000Z", true},
+		{"2020-03-22T13:22:13.933123456Z", "2020-03-22T13:22:13.933Z", true},
+		{"2020-03-22T09:22:13.933-04:00", "2020-03-22T13:22:13.933Z", true},
+		{"2020-03-22T09:22:13.933-0400", "2020-03-22T13:22:13.933Z", true},
+		{"2020-03-22T15:22:13+02", "2020-03-22T13:22:13.000Z", true},
+		{"2020-03-22T13:22:13.933", "2020-03-22T13:22:13.933Z", true},
+		{"2020-03-22 13:22:13", "2020-03-22T13:22:13.000Z", true},
+		{"1584883333933", "2020-03-22T13:22:13.933Z", true},
+		{"1584883333", "2020-03-22T13:22:13.000Z", true},
+		{"", "", true},
+		{"yesterday", "", false},
+		{"22/03/2020", "", false},
+		// basic ISO 8601 dates and years aren't epoch seconds
+		{"20261019", "", false},
+		{"2026", "", false},
+		{"+158488333", "", false},
+	}
+
+	for _, tc := range testCases {
+		actual, ok := normalizeTimestamp(tc.timestamp)
+		if actual != tc.expected || ok != tc.ok {
+			t.Errorf("ERROR: normalizing %q: expected %q, %t, got %q, %t", tc.timestamp, tc.expected, tc.ok, actual, ok)
+		}
+	}
+}
+
+func TestUnparseableTimestamps(t *testing.T) {
+	h := HubCollector{Summary: summary.NewBuilder()}
+	for _, finding := range []types.AwsSecurityFinding{
+		{Id: aws.String("f1"), ProductName: aws.String("Inspector"), CreatedAt: aws.String("yesterday"), UpdatedAt: aws.String("today")},
+		{Id: aws.String("f2"), ProductName: aws.String("Inspector"), CreatedAt: aws.String("2020-03-22T13:22:13Z")},
+		{Id: aws.String("f3"), ProductName: aws.String("GuardDuty"), FirstObservedAt: aws.String("n/a")},
+	} {
+		h.timestamp(finding.CreatedAt, finding)
+		h.timestamp(finding.UpdatedAt, finding)
+		h.timestamp(finding.FirstObservedAt, finding)
+	}
+
+	expected := []summary.Count{{Key: "Inspector", Rows: 2, Findings: 1}, {Key: "GuardDuty", Rows: 1, Findings: 1}}
+	if diff := cmp.Diff(expected, h.Summary.Summary().UnparseableTimestamps); diff != "" {
+		t.Errorf("ERROR: unparseable timestamps mismatch (-expected +actual):\n%s", diff)
+	}
+}
+
+func TestObservedColumns(t *testing.T) {
+	finding := columnFinding()
+	finding.FirstObservedAt = aws.String("1584883333")
+	finding.LastObservedAt = aws.String("2020-03-23T13:22:13Z")
+
+	testCases := []struct {
+		name     string
+		h        HubCollector
+		expected map[string]string
+	}{
+		{
+			name:     "default",
+			h:        HubCollector{},
+			expected: map[string]string{},
+		},
+		{
+			name: "observed columns",
+			h:    HubCollector{ObservedColumns: true},
+			expected: map[string]string{
+				"First Observed At": "2020-03-22T13:22:13.000Z",
+				"Last Observed At":  "2020-03-23T13:22:13.000Z",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := columnValues(t, tc.h, finding, teams.Account{ID: "000000000001"}, clock.NewMock(), "First Observed At", "Last Observed At")
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: observed columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package securityhubcollector
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+)
+
+// setVulnerabilityColumns sets the vulnerability columns of a record from a finding's
+// vulnerabilities, which Inspector and other products report for CVE-based findings. A finding
+// can have more than one vulnerability, so lists are joined with commas and scores are the
+// highest of them. EPSS scores come from the EPSS feed, if it is loaded, falling back to the
+// score the product reported.
+func (h *HubCollector) setVulnerabilityColumns(record *FindingRecord, vulnerabilities []types.Vulnerability) {
+	if len(vulnerabilities) == 0 {
+		return
+	}
+
+	var ids, packages, fixedIn []string
+	var cvss, epss float64
+	hasCVSS, hasEPSS := false, false
+	exploitAvailable, knownExploited := "", false
+	for _, vulnerability := range vulnerabilities {
+		id := aws.ToString(vulnerability.Id)
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+
+		for _, score := range vulnerability.Cvss {
+			if score.BaseScore != nil && (!hasCVSS || *score.BaseScore > cvss) {
+				cvss, hasCVSS = *score.BaseScore, true
+			}
+		}
+
+		score, ok := h.EPSS.Score(id)
+		if !ok && vulnerability.EpssScore != nil {
+			score, ok = *vulnerability.EpssScore, true
+		}
+		if ok && (!hasEPSS || score > epss) {
+			epss, hasEPSS = score, true
+		}
+
+		for _, p := range vulnerability.VulnerablePackages {
+			name := aws.ToString(p.Name)
+			if installed := strings.TrimSpace(name + " " + aws.ToString(p.Version)); installed != "" && !slices.Contains(packages, installed) {
+				packages = append(packages, installed)
+			}
+			if fixed := aws.ToString(p.FixedInVersion); fixed != "" && fixed != "NotAvailable" {
+				if version := strings.TrimSpace(name + " " + fixed); !slices.Contains(fixedIn, version) {
+					fixedIn = append(fixedIn, version)
+				}
+			}
+		}
+
+		switch vulnerability.ExploitAvailable {
+		case types.VulnerabilityExploitAvailableYes:
+			exploitAvailable = "true"
+		case types.VulnerabilityExploitAvailableNo:
+			if exploitAvailable == "" {
+				exploitAvailable = "false"
+			}
+		}
+		knownExploited = knownExploited || h.KEV.Contains(id)
+	}
+
+	record.CVEIDs = strings.Join(ids, ", ")
+	record.VulnerablePackages = strings.Join(packages, ", ")
+	record.FixedInVersions = strings.Join(fixedIn, ", ")
+	record.ExploitAvailable = exploitAvailable
+	if hasCVSS {
+		record.CVSSScore = strconv.FormatFloat(cvss, 'f', 1, 64)
+	}
+	if hasEPSS {
+		record.EPSSScore = strconv.FormatFloat(epss, 'f', -1, 64)
+	}
+	if h.KEV != nil {
+		record.KnownExploited = strconv.FormatBool(knownExploited)
+	}
+}
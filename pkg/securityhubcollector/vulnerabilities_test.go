@@ -0,0 +1,114 @@
+package securityhubcollector
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/feeds"
+)
+
+func TestVulnerabilityColumns(t *testing.T) {
+	vulnerabilities := []types.Vulnerability{
+		{
+			Id:               aws.String("CVE-2021-44228"),
+			Cvss:             []types.Cvss{{BaseScore: aws.Float64(9.8), Version: aws.String("3.1")}, {BaseScore: aws.Float64(10), Version: aws.String("3.0")}},
+			EpssScore:        aws.Float64(0.9),
+			ExploitAvailable: types.VulnerabilityExploitAvailableYes,
+			VulnerablePackages: []types.SoftwarePackage{
+				{Name: aws.String("log4j-core"), Version: aws.String("2.14.1"), FixedInVersion: aws.String("2.17.1")},
+			},
+		},
+		{
+			Id:               aws.String("CVE-2022-0001"),
+			Cvss:             []types.Cvss{{BaseScore: aws.Float64(5.5)}},
+			EpssScore:        aws.Float64(0.001),
+			ExploitAvailable: types.VulnerabilityExploitAvailableNo,
+			VulnerablePackages: []types.SoftwarePackage{
+				{Name: aws.String("log4j-core"), Version: aws.String("2.14.1"), FixedInVersion: aws.String("NotAvailable")},
+				{Name: aws.String("openssl"), Version: aws.String("1.1.1k")},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		h               HubCollector
+		vulnerabilities []types.Vulnerability
+		expected        []string
+	}{
+		{
+			name:            "reported by the product",
+			vulnerabilities: vulnerabilities,
+			expected:        []string{"CVE-2021-44228, CVE-2022-0001", "10.0", "log4j-core 2.14.1, openssl 1.1.1k", "log4j-core 2.17.1", "true", "0.9", ""},
+		},
+		{
+			name:            "enriched from feeds",
+			h:               HubCollector{EPSS: feeds.EPSS{"CVE-2022-0001": 0.97}, KEV: feeds.KEV{"CVE-2021-44228": true}},
+			vulnerabilities: vulnerabilities,
+			expected:        []string{"CVE-2021-44228, CVE-2022-0001", "10.0", "log4j-core 2.14.1, openssl 1.1.1k", "log4j-core 2.17.1", "true", "0.97", "true"},
+		},
+		{
+			name:            "not known exploited",
+			h:               HubCollector{KEV: feeds.KEV{"CVE-2021-44228": true}},
+			vulnerabilities: vulnerabilities[1:],
+			expected:        []string{"CVE-2022-0001", "5.5", "log4j-core 2.14.1, openssl 1.1.1k", "", "false", "0.001", "false"},
+		},
+		{
+			name:     "no vulnerabilities",
+			h:        HubCollector{KEV: feeds.KEV{"CVE-2021-44228": true}},
+			expected: []string{"", "", "", "", "", "", ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var record FindingRecord
+			tc.h.setVulnerabilityColumns(&record, tc.vulnerabilities)
+			actual := []string{record.CVEIDs, record.CVSSScore, record.VulnerablePackages, record.FixedInVersions, record.ExploitAvailable, record.EPSSScore, record.KnownExploited}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: vulnerability columns mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVulnerabilityColumnHeaders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		h        HubCollector
+		expected []string
+	}{
+		{
+			name: "no vulnerability columns by default",
+		},
+		{
+			name:     "vulnerability columns",
+			h:        HubCollector{VulnerabilityColumns: true},
+			expected: []string{"CVE IDs", "CVSS Score", "Vulnerable Packages", "Fixed In Versions", "Exploit Available"},
+		},
+		{
+			name:     "feeds",
+			h:        HubCollector{EPSS: feeds.EPSS{}, KEV: feeds.KEV{}},
+			expected: []string{"EPSS Score", "Known Exploited"},
+		},
+	}
+
+	columns := []string{"CVE IDs", "CVSS Score", "Vulnerable Packages", "Fixed In Versions", "Exploit Available", "EPSS Score", "Known Exploited"}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, header := range tc.h.headers() {
+				if slices.Contains(columns, header) {
+					actual = append(actual, header)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: vulnerability headers mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package severity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/securityhub/types"
+)
+
+// Overrides is a struct describing the format we expect in the JSON file describing severity
+// overrides
+type Overrides struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule remaps the severity of the rows it matches. A row matches the rule if it matches every
+// criterion that is set; lists match if any of their values do.
+type Rule struct {
+	ID string `json:"id"`
+	// Severity is the effective severity label of matching rows
+	Severity string `json:"severity"`
+
+	Products     []string `json:"products"`
+	ControlIDs   []string `json:"controlIds"`
+	Environments []string `json:"environments"`
+	Teams        []string `json:"teams"`
+	// Severities are the original severity labels the rule applies to
+	Severities []string `json:"severities"`
+}
+
+// Row is the fields of a row that rules match on
+type Row struct {
+	Product     string
+	ControlID   string
+	Environment string
+	Team        string
+	Severity    string
+}
+
+// ParseOverridesFile reads and validates a JSON file describing severity overrides
+func ParseOverridesFile(path string) (*Overrides, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading severity overrides file: %s", err)
+	}
+
+	var overrides Overrides
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&overrides)
+	if err != nil {
+		return nil, fmt.Errorf("error JSON decoding severity overrides: %s", err)
+	}
+
+	err = overrides.normalize()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing severity overrides: %w", err)
+	}
+
+	return &overrides, nil
+}
+
+// normalize upper-cases the severity labels of the rules and checks that every rule has a unique
+// ID, a known severity and at least one criterion
+func (o *Overrides) normalize() error {
+	var seen []string
+	for i := range o.Rules {
+		rule := &o.Rules[i]
+		if rule.ID == "" {
+			return fmt.Errorf("severity override %d is missing an ID", i+1)
+		}
+		if slices.Contains(seen, rule.ID) {
+			return fmt.Errorf("duplicate severity override ID: %s", rule.ID)
+		}
+		seen = append(seen, rule.ID)
+
+		label, err := normalizeLabel(rule.Severity)
+		if err != nil {
+			return fmt.Errorf("severity override %s: %w", rule.ID, err)
+		}
+		rule.Severity = label
+		for j, severity := range rule.Severities {
+			rule.Severities[j], err = normalizeLabel(severity)
+			if err != nil {
+				return fmt.Errorf("severity override %s: %w", rule.ID, err)
+			}
+		}
+
+		if len(rule.Products)+len(rule.ControlIDs)+len(rule.Environments)+len(rule.Teams)+len(rule.Severities) == 0 {
+			return fmt.Errorf("severity override %s doesn't match on anything", rule.ID)
+		}
+	}
+	return nil
+}
+
+// normalizeLabel returns the upper-case Security Hub severity label, or an error if it isn't one
+func normalizeLabel(severity string) (string, error) {
+	label := types.SeverityLabel(strings.ToUpper(strings.TrimSpace(severity)))
+	if !slices.Contains(label.Values(), label) {
+		return "", fmt.Errorf("unknown severity %q", severity)
+	}
+	return string(label), nil
+}
+
+// Matches returns true if the row matches every criterion of the rule that is set
+func (r Rule) Matches(row Row) bool {
+	return matchesAny(r.Products, row.Product) &&
+		matchesAny(r.ControlIDs, row.ControlID) &&
+		matchesAny(r.Environments, row.Environment) &&
+		matchesAny(r.Teams, row.Team) &&
+		matchesAny(r.Severities, row.Severity)
+}
+
+// Apply returns the effective severity of a row and the ID of the rule that set it. The first
+// rule that matches wins; if none do, the row keeps its severity and the rule ID is empty.
+func (o *Overrides) Apply(row Row) (string, string) {
+	if o != nil {
+		for _, rule := range o.Rules {
+			if rule.Matches(row) {
+				return rule.Severity, rule.ID
+			}
+		}
+	}
+	return row.Severity, ""
+}
+
+// matchesAny returns true if there are no values or one of them is value, ignoring case
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
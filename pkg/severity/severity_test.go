@@ -0,0 +1,68 @@
+package severity
+
+import "testing"
+
+func TestParseOverridesFile(t *testing.T) {
+	overrides, err := ParseOverridesFile("severity_overrides_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid severity overrides file: %s", err)
+	}
+	if len(overrides.Rules) != 2 || overrides.Rules[0].Severity != "LOW" {
+		t.Errorf("ERROR: unexpected severity overrides: %+v", overrides.Rules)
+	}
+}
+
+func TestNormalizeOverrides(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule Rule
+	}{
+		{"missing ID", Rule{Severity: "LOW", Teams: []string{"team"}}},
+		{"unknown severity", Rule{ID: "SEV-001", Severity: "MINOR", Teams: []string{"team"}}},
+		{"unknown original severity", Rule{ID: "SEV-001", Severity: "LOW", Severities: []string{"MAJOR"}}},
+		{"no criteria", Rule{ID: "SEV-001", Severity: "LOW"}},
+	}
+	for _, tc := range testCases {
+		overrides := Overrides{Rules: []Rule{tc.rule}}
+		if err := overrides.normalize(); err == nil {
+			t.Errorf("ERROR: expected an error for a rule with %s", tc.name)
+		}
+	}
+
+	rule := Rule{ID: "SEV-001", Severity: "LOW", Teams: []string{"team"}}
+	overrides := Overrides{Rules: []Rule{rule, rule}}
+	if err := overrides.normalize(); err == nil {
+		t.Error("ERROR: expected an error for duplicate rule IDs")
+	}
+}
+
+func TestApply(t *testing.T) {
+	overrides, err := ParseOverridesFile("severity_overrides_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid severity overrides file: %s", err)
+	}
+
+	testCases := []struct {
+		name     string
+		row      Row
+		severity string
+		ruleID   string
+	}{
+		{"non-production", Row{Product: "inspector", Environment: "dev", Severity: "HIGH"}, "LOW", "SEV-001"},
+		{"production", Row{Product: "Inspector", Environment: "prod", Severity: "HIGH"}, "HIGH", ""},
+		{"original severity not listed", Row{Product: "Inspector", Environment: "dev", Severity: "MEDIUM"}, "MEDIUM", ""},
+		{"control and team", Row{ControlID: "IAM.6", Team: "Test Team 1", Severity: "LOW"}, "CRITICAL", "SEV-002"},
+		{"other team", Row{ControlID: "IAM.6", Team: "Test Team 2", Severity: "LOW"}, "LOW", ""},
+	}
+	for _, tc := range testCases {
+		severity, ruleID := overrides.Apply(tc.row)
+		if severity != tc.severity || ruleID != tc.ruleID {
+			t.Errorf("ERROR: %s: expected %s by %q, got %s by %q", tc.name, tc.severity, tc.ruleID, severity, ruleID)
+		}
+	}
+
+	var noOverrides *Overrides
+	if severity, ruleID := noOverrides.Apply(Row{Severity: "HIGH"}); severity != "HIGH" || ruleID != "" {
+		t.Errorf("ERROR: expected nil overrides to keep the severity, got %s by %q", severity, ruleID)
+	}
+}
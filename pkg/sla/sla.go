@@ -0,0 +1,171 @@
+package sla
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severities are the Security Hub severity labels that an SLA can be set for
+var Severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "INFORMATIONAL"}
+
+// Policy is a struct describing the format we expect in the JSON file describing remediation
+// SLAs. Days maps severity labels to the number of days findings of that severity must be
+// remediated in. Overrides replace some of those days for a team, an environment, or both.
+type Policy struct {
+	Days      map[string]int `json:"days"`
+	Overrides []Override     `json:"overrides"`
+}
+
+// Override sets the SLA days of some severities for findings of a team, an environment, or both.
+// Severities it doesn't list keep the days of less specific overrides or the policy.
+type Override struct {
+	Team        string         `json:"team"`
+	Environment string         `json:"environment"`
+	Days        map[string]int `json:"days"`
+}
+
+// Status is the SLA status of a finding at a point in time. Ages and days remaining are counted
+// in calendar days in UTC.
+type Status struct {
+	AgeDays int
+	// HasSLA is false if the policy sets no SLA for the finding's severity, in which case the
+	// other fields are zero
+	HasSLA        bool
+	Due           time.Time
+	DaysRemaining int
+	Overdue       bool
+}
+
+// ParsePolicyFile reads and validates a JSON file describing an SLA policy
+func ParsePolicyFile(path string) (*Policy, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading SLA policy file: %s", err)
+	}
+
+	var policy Policy
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&policy)
+	if err != nil {
+		return nil, fmt.Errorf("error JSON decoding SLA policy: %s", err)
+	}
+
+	err = policy.normalize()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SLA policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// normalize upper-cases the severities of the policy and checks that they are known, that days
+// aren't negative, and that every override names a team or an environment
+func (p *Policy) normalize() error {
+	days, err := normalizeDays(p.Days)
+	if err != nil {
+		return err
+	}
+	p.Days = days
+
+	for i, override := range p.Overrides {
+		if override.Team == "" && override.Environment == "" {
+			return fmt.Errorf("SLA override %d has neither a team nor an environment", i+1)
+		}
+		days, err := normalizeDays(override.Days)
+		if err != nil {
+			return fmt.Errorf("SLA override %d: %w", i+1, err)
+		}
+		p.Overrides[i].Days = days
+	}
+	return nil
+}
+
+// normalizeDays returns a copy of days keyed by upper-case severity labels
+func normalizeDays(days map[string]int) (map[string]int, error) {
+	normalized := make(map[string]int, len(days))
+	for severity, n := range days {
+		label := strings.ToUpper(strings.TrimSpace(severity))
+		if !slices.Contains(Severities, label) {
+			return nil, fmt.Errorf("unknown severity %q; expected one of %s", severity, strings.Join(Severities, ", "))
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("negative SLA days for severity %s: %d", label, n)
+		}
+		normalized[label] = n
+	}
+	return normalized, nil
+}
+
+// DaysFor returns the number of days findings of a severity, team and environment must be
+// remediated in, and false if the policy sets no SLA for them. An override for both the team and
+// the environment wins over one for the team, which wins over one for the environment; among
+// overrides that are equally specific, the first one wins.
+func (p *Policy) DaysFor(severity, team, environment string) (int, bool) {
+	severity = strings.ToUpper(severity)
+	days, ok := p.Days[severity]
+	bestScore := 0
+	for _, override := range p.Overrides {
+		score := override.score(team, environment)
+		if score <= bestScore {
+			continue
+		}
+		if n, found := override.Days[severity]; found {
+			days, ok, bestScore = n, true, score
+		}
+	}
+	return days, ok
+}
+
+// score returns how specifically the override matches a team and environment, or 0 if it doesn't
+func (o Override) score(team, environment string) int {
+	if o.Team != "" && !strings.EqualFold(o.Team, team) {
+		return 0
+	}
+	if o.Environment != "" && !strings.EqualFold(o.Environment, environment) {
+		return 0
+	}
+	score := 0
+	if o.Team != "" {
+		score += 2
+	}
+	if o.Environment != "" {
+		score++
+	}
+	return score
+}
+
+// Evaluate returns the SLA status at now of a finding of a severity, team and environment that
+// was first observed at start
+func (p *Policy) Evaluate(severity, team, environment string, start, now time.Time) Status {
+	startDate := date(start)
+	today := date(now)
+	status := Status{AgeDays: daysBetween(startDate, today)}
+
+	days, ok := p.DaysFor(severity, team, environment)
+	if !ok {
+		return status
+	}
+	status.HasSLA = true
+	status.Due = startDate.AddDate(0, 0, days)
+	status.DaysRemaining = daysBetween(today, status.Due)
+	status.Overdue = status.DaysRemaining < 0
+	return status
+}
+
+// date returns midnight UTC on the date of t in UTC
+func date(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of days from one date to another, both at midnight UTC
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,100 @@
+package sla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePolicyFile(t *testing.T) {
+	policy, err := ParsePolicyFile("sla_policy_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid SLA policy file: %s", err)
+	}
+	if policy.Days["CRITICAL"] != 15 || len(policy.Overrides) != 3 {
+		t.Errorf("ERROR: unexpected SLA policy: %+v", policy)
+	}
+
+	// this test checks that an unknown severity is caught
+	_, err = ParsePolicyFile("sla_policy_test_invalid_severity.json")
+	if err == nil {
+		t.Error("ERROR: didn't get expected error for unknown severity")
+	}
+}
+
+func TestDaysFor(t *testing.T) {
+	policy, err := ParsePolicyFile("sla_policy_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid SLA policy file: %s", err)
+	}
+
+	testCases := []struct {
+		severity    string
+		team        string
+		environment string
+		days        int
+		ok          bool
+	}{
+		{"CRITICAL", "Test Team 2", "dev", 15, true},
+		{"critical", "Test Team 2", "prod", 7, true},
+		{"CRITICAL", "Test Team 1", "dev", 15, true},
+		{"CRITICAL", "Test Team 1", "prod", 3, true},
+		{"HIGH", "Test Team 1", "prod", 45, true},
+		{"HIGH", "Test Team 2", "prod", 14, true},
+		{"MEDIUM", "Test Team 1", "prod", 90, true},
+		{"INFORMATIONAL", "Test Team 1", "prod", 0, false},
+		{"", "Test Team 1", "prod", 0, false},
+	}
+	for _, tc := range testCases {
+		days, ok := policy.DaysFor(tc.severity, tc.team, tc.environment)
+		if days != tc.days || ok != tc.ok {
+			t.Errorf("ERROR: SLA days for %s/%s/%s: expected %d, %t, got %d, %t", tc.severity, tc.team, tc.environment, tc.days, tc.ok, days, ok)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy := &Policy{Days: map[string]int{"HIGH": 30}}
+	start := time.Date(2023, 1, 1, 23, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		severity string
+		now      time.Time
+		expected Status
+	}{
+		{
+			name:     "within SLA",
+			severity: "HIGH",
+			now:      time.Date(2023, 1, 11, 1, 0, 0, 0, time.UTC),
+			expected: Status{AgeDays: 10, HasSLA: true, Due: time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), DaysRemaining: 20},
+		},
+		{
+			name:     "due today",
+			severity: "HIGH",
+			now:      time.Date(2023, 1, 31, 23, 59, 0, 0, time.UTC),
+			expected: Status{AgeDays: 30, HasSLA: true, Due: time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), DaysRemaining: 0},
+		},
+		{
+			name:     "overdue",
+			severity: "HIGH",
+			now:      time.Date(2023, 2, 5, 0, 0, 0, 0, time.UTC),
+			expected: Status{AgeDays: 35, HasSLA: true, Due: time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), DaysRemaining: -5, Overdue: true},
+		},
+		{
+			name:     "no SLA",
+			severity: "LOW",
+			now:      time.Date(2023, 2, 5, 0, 0, 0, 0, time.UTC),
+			expected: Status{AgeDays: 35},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := policy.Evaluate(tc.severity, "Test Team", "dev", start, tc.now)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("ERROR: SLA status mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Row is the part of an output row that the summary counts
+type Row struct {
+	FindingID        string
+	Team             string
+	AccountID        string
+	Region           string
+	SeverityLabel    string
+	ComplianceStatus string
+	Product          string
+}
+
+// Count is the number of rows and distinct findings for one value of a dimension
+type Count struct {
+	Key      string `json:"key"`
+	Rows     int    `json:"rows"`
+	Findings int    `json:"findings"`
+}
+
+// Job is the outcome of collecting findings from one account in one region
+type Job struct {
+	Team      string
+	AccountID string
+	Region    string
+	Findings  int
+	Rows      int
+	APICalls  int
+	Duration  time.Duration
+	Error     string
+}
+
+// AccountStats describes the collection from one account, across all of its regions. Findings
+// counts the findings Security Hub returned, including those whose rows suppression excluded.
+type AccountStats struct {
+	AccountID       string   `json:"accountId"`
+	Team            string   `json:"team"`
+	Regions         int      `json:"regions"`
+	Findings        int      `json:"findings"`
+	Rows            int      `json:"rows"`
+	APICalls        int      `json:"apiCalls"`
+	DurationSeconds float64  `json:"durationSeconds"`
+	Errors          []string `json:"errors,omitempty"`
+}
+
+// ExpiredRule describes a suppression rule that has expired and needs to be renewed or removed
+type ExpiredRule struct {
+	ID      string `json:"id"`
+	Owner   string `json:"owner"`
+	Ticket  string `json:"ticket,omitempty"`
+	Expires string `json:"expires"`
+}
+
+// Summary counts output rows and distinct findings, overall and by dimension. When jobs are
+// added, it also describes the collection from each account.
+type Summary struct {
+	Rows               int     `json:"rows"`
+	Findings           int     `json:"findings"`
+	ByTeam             []Count `json:"byTeam"`
+	ByAccount          []Count `json:"byAccount"`
+	ByRegion           []Count `json:"byRegion"`
+	BySeverity         []Count `json:"bySeverity"`
+	ByComplianceStatus []Count `json:"byComplianceStatus"`
+	ByProduct          []Count `json:"byProduct"`
+
+	DuplicateRows         int            `json:"duplicateRows,omitempty"`
+	AlteredValues         []Count        `json:"alteredValues,omitempty"`
+	UnparseableTimestamps []Count        `json:"unparseableTimestamps,omitempty"`
+	ExcludedRows          int            `json:"excludedRows,omitempty"`
+	SuppressedRows        []Count        `json:"suppressedRows,omitempty"`
+	ExpiredRules          []ExpiredRule  `json:"expiredRules,omitempty"`
+	APICalls              int            `json:"apiCalls,omitempty"`
+	Accounts              []AccountStats `json:"accounts,omitempty"`
+	ZeroFindingAccounts   []string       `json:"zeroFindingAccounts,omitempty"`
+}
+
+// dimension accumulates rows and distinct findings per key
+type dimension struct {
+	rows     map[string]int
+	findings map[string]map[string]bool
+}
+
+func newDimension() *dimension {
+	return &dimension{rows: make(map[string]int), findings: make(map[string]map[string]bool)}
+}
+
+func (d *dimension) add(key, findingID string) {
+	d.rows[key]++
+	if d.findings[key] == nil {
+		d.findings[key] = make(map[string]bool)
+	}
+	d.findings[key][findingID] = true
+}
+
+// counts returns the counts sorted by descending rows, then key
+func (d *dimension) counts() []Count {
+	counts := make([]Count, 0, len(d.rows))
+	for key, rows := range d.rows {
+		counts = append(counts, Count{Key: key, Rows: rows, Findings: len(d.findings[key])})
+	}
+	slices.SortFunc(counts, func(a, b Count) int {
+		if a.Rows != b.Rows {
+			return b.Rows - a.Rows
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return counts
+}
+
+// Builder accumulates rows and jobs into a Summary
+type Builder struct {
+	rows       int
+	duplicates int
+	excluded   int
+	expired    []ExpiredRule
+	findings   map[string]bool
+	dimensions map[string]*dimension
+	accounts   map[string]*AccountStats
+}
+
+// NewBuilder returns an empty Builder
+func NewBuilder() *Builder {
+	return &Builder{
+		findings:   make(map[string]bool),
+		dimensions: make(map[string]*dimension),
+		accounts:   make(map[string]*AccountStats),
+	}
+}
+
+// AddJob records the outcome of collecting findings from one account in one region. The
+// job's rows are counted by Add; the job only contributes its account's statistics.
+func (b *Builder) AddJob(job Job) {
+	stats := b.accounts[job.AccountID]
+	if stats == nil {
+		stats = &AccountStats{AccountID: job.AccountID, Team: job.Team}
+		b.accounts[job.AccountID] = stats
+	}
+	stats.Regions++
+	stats.Findings += job.Findings
+	stats.Rows += job.Rows
+	stats.APICalls += job.APICalls
+	stats.DurationSeconds += job.Duration.Seconds()
+	if job.Error != "" {
+		stats.Errors = append(stats.Errors, job.Region+": "+job.Error)
+	}
+}
+
+// AddDuplicates counts rows that were removed as duplicates of an output row
+func (b *Builder) AddDuplicates(n int) {
+	b.duplicates += n
+}
+
+// AddAlteredValue counts a value in the given column that was altered by sanitization
+func (b *Builder) AddAlteredValue(column, findingID string) {
+	b.dimension("altered").add(column, findingID)
+}
+
+// AddSuppressed counts a row of a finding that matched the suppression rule with the given ID.
+// Rows that the rule excluded from the output are also counted as excluded.
+func (b *Builder) AddSuppressed(ruleID, findingID string, excluded bool) {
+	b.dimension("suppressed").add(ruleID, findingID)
+	if excluded {
+		b.excluded++
+	}
+}
+
+// AddExpiredRule records a suppression rule that has expired
+func (b *Builder) AddExpiredRule(rule ExpiredRule) {
+	b.expired = append(b.expired, rule)
+}
+
+// AddUnparseableTimestamp counts a timestamp of a finding from the given product that couldn't be parsed
+func (b *Builder) AddUnparseableTimestamp(product, findingID string) {
+	b.dimension("unparseable timestamps").add(valueOrNone(product), findingID)
+}
+
+// Add counts a single output row
+func (b *Builder) Add(row Row) {
+	b.rows++
+	b.findings[row.FindingID] = true
+	b.dimension("team").add(valueOrNone(row.Team), row.FindingID)
+	b.dimension("account").add(valueOrNone(row.AccountID), row.FindingID)
+	b.dimension("region").add(valueOrNone(row.Region), row.FindingID)
+	b.dimension("severity").add(valueOrNone(row.SeverityLabel), row.FindingID)
+	b.dimension("compliance").add(valueOrNone(row.ComplianceStatus), row.FindingID)
+	b.dimension("product").add(valueOrNone(row.Product), row.FindingID)
+}
+
+func (b *Builder) dimension(name string) *dimension {
+	if b.dimensions[name] == nil {
+		b.dimensions[name] = newDimension()
+	}
+	return b.dimensions[name]
+}
+
+// Summary returns the summary of the rows and jobs added so far
+func (b *Builder) Summary() Summary {
+	s := Summary{
+		Rows:               b.rows,
+		Findings:           len(b.findings),
+		DuplicateRows:      b.duplicates,
+		ExcludedRows:       b.excluded,
+		ExpiredRules:       b.expired,
+		ByTeam:             b.dimension("team").counts(),
+		ByAccount:          b.dimension("account").counts(),
+		ByRegion:           b.dimension("region").counts(),
+		BySeverity:         b.dimension("severity").counts(),
+		ByComplianceStatus: b.dimension("compliance").counts(),
+		ByProduct:          b.dimension("product").counts(),
+	}
+
+	if altered := b.dimension("altered").counts(); len(altered) > 0 {
+		s.AlteredValues = altered
+	}
+	if unparseable := b.dimension("unparseable timestamps").counts(); len(unparseable) > 0 {
+		s.UnparseableTimestamps = unparseable
+	}
+	if suppressed := b.dimension("suppressed").counts(); len(suppressed) > 0 {
+		s.SuppressedRows = suppressed
+	}
+
+	for _, stats := range b.accounts {
+		s.APICalls += stats.APICalls
+		s.Accounts = append(s.Accounts, *stats)
+		if stats.Findings == 0 {
+			s.ZeroFindingAccounts = append(s.ZeroFindingAccounts, stats.AccountID)
+		}
+	}
+	// slowest accounts first, since those are the ones worth looking into
+	slices.SortFunc(s.Accounts, func(a, b AccountStats) int {
+		if a.DurationSeconds != b.DurationSeconds {
+			if a.DurationSeconds > b.DurationSeconds {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.AccountID, b.AccountID)
+	})
+	slices.Sort(s.ZeroFindingAccounts)
+	return s
+}
+
+// valueOrNone labels empty values so they still show up in the summary
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// WriteText writes the summary as human-readable tables
+func (s Summary) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d rows, %d findings\n", s.Rows, s.Findings)
+	if s.DuplicateRows > 0 {
+		fmt.Fprintf(tw, "%d duplicate rows removed\n", s.DuplicateRows)
+	}
+	if s.ExcludedRows > 0 {
+		fmt.Fprintf(tw, "%d rows excluded by suppression rules\n", s.ExcludedRows)
+	}
+	if len(s.Accounts) > 0 {
+		fmt.Fprintf(tw, "%d accounts, %d API calls\n", len(s.Accounts), s.APICalls)
+	}
+
+	sections := []struct {
+		title  string
+		counts []Count
+	}{
+		{"TEAM", s.ByTeam},
+		{"ACCOUNT", s.ByAccount},
+		{"REGION", s.ByRegion},
+		{"SEVERITY", s.BySeverity},
+		{"COMPLIANCE STATUS", s.ByComplianceStatus},
+		{"PRODUCT", s.ByProduct},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(tw, "\n%s\tROWS\tFINDINGS\n", section.title)
+		for _, c := range section.counts {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", c.Key, c.Rows, c.Findings)
+		}
+	}
+
+	if len(s.AlteredValues) > 0 {
+		fmt.Fprintf(tw, "\nALTERED BY SANITIZATION\tROWS\tFINDINGS\n")
+		for _, c := range s.AlteredValues {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", c.Key, c.Rows, c.Findings)
+		}
+	}
+
+	if len(s.UnparseableTimestamps) > 0 {
+		fmt.Fprintf(tw, "\nUNPARSEABLE TIMESTAMPS BY PRODUCT\tVALUES\tFINDINGS\n")
+		for _, c := range s.UnparseableTimestamps {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", c.Key, c.Rows, c.Findings)
+		}
+	}
+
+	if len(s.SuppressedRows) > 0 {
+		fmt.Fprintf(tw, "\nSUPPRESSION RULE\tROWS\tFINDINGS\n")
+		for _, c := range s.SuppressedRows {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", c.Key, c.Rows, c.Findings)
+		}
+	}
+	if len(s.ExpiredRules) > 0 {
+		fmt.Fprintf(tw, "\nEXPIRED SUPPRESSION RULE\tOWNER\tTICKET\tEXPIRED\n")
+		for _, rule := range s.ExpiredRules {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rule.ID, rule.Owner, rule.Ticket, rule.Expires)
+		}
+	}
+
+	if len(s.Accounts) > 0 {
+		fmt.Fprintf(tw, "\nACCOUNT\tTEAM\tREGIONS\tFINDINGS\tROWS\tAPI CALLS\tDURATION\tERRORS\n")
+		for _, a := range s.Accounts {
+			duration := time.Duration(a.DurationSeconds * float64(time.Second)).Round(time.Millisecond)
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n", a.AccountID, a.Team, a.Regions, a.Findings, a.Rows, a.APICalls, duration, strings.Join(a.Errors, "; "))
+		}
+	}
+	if len(s.ZeroFindingAccounts) > 0 {
+		fmt.Fprintf(tw, "\nACCOUNTS WITH ZERO FINDINGS\n")
+		for _, accountID := range s.ZeroFindingAccounts {
+			fmt.Fprintf(tw, "%s\n", accountID)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,87 @@
+package summary
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSummary(t *testing.T) {
+	builder := NewBuilder()
+	rows := []Row{
+		{FindingID: "finding1", Team: "Test Team 1", AccountID: "000000000001", Region: "us-east-1", SeverityLabel: "HIGH", ComplianceStatus: "FAILED", Product: "Security Hub"},
+		{FindingID: "finding1", Team: "Test Team 1", AccountID: "000000000001", Region: "us-east-1", SeverityLabel: "HIGH", ComplianceStatus: "FAILED", Product: "Security Hub"},
+		{FindingID: "finding2", Team: "Test Team 2", AccountID: "000000000002", Region: "us-west-2", SeverityLabel: "LOW", Product: "Inspector"},
+	}
+	for _, row := range rows {
+		builder.Add(row)
+	}
+
+	expected := Summary{
+		Rows:               3,
+		Findings:           2,
+		ByTeam:             []Count{{Key: "Test Team 1", Rows: 2, Findings: 1}, {Key: "Test Team 2", Rows: 1, Findings: 1}},
+		ByAccount:          []Count{{Key: "000000000001", Rows: 2, Findings: 1}, {Key: "000000000002", Rows: 1, Findings: 1}},
+		ByRegion:           []Count{{Key: "us-east-1", Rows: 2, Findings: 1}, {Key: "us-west-2", Rows: 1, Findings: 1}},
+		BySeverity:         []Count{{Key: "HIGH", Rows: 2, Findings: 1}, {Key: "LOW", Rows: 1, Findings: 1}},
+		ByComplianceStatus: []Count{{Key: "FAILED", Rows: 2, Findings: 1}, {Key: "(none)", Rows: 1, Findings: 1}},
+		ByProduct:          []Count{{Key: "Security Hub", Rows: 2, Findings: 1}, {Key: "Inspector", Rows: 1, Findings: 1}},
+	}
+	actual := builder.Summary()
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("Expected summary did not match actual: %s", diff)
+	}
+
+	var b bytes.Buffer
+	err := actual.WriteText(&b)
+	if err != nil {
+		t.Fatalf("could not write summary text: %s", err)
+	}
+	if !strings.HasPrefix(b.String(), "3 rows, 2 findings\n") {
+		t.Errorf("ERROR: unexpected summary text:\n%s", b.String())
+	}
+}
+
+func TestSummaryJobs(t *testing.T) {
+	builder := NewBuilder()
+	builder.Add(Row{FindingID: "finding1", Team: "Test Team 1", AccountID: "000000000001", Region: "us-east-1"})
+	jobs := []Job{
+		{Team: "Test Team 1", AccountID: "000000000001", Region: "us-east-1", Findings: 1, Rows: 1, APICalls: 1, Duration: 1500 * time.Millisecond},
+		{Team: "Test Team 1", AccountID: "000000000001", Region: "us-west-2", APICalls: 1, Duration: 500 * time.Millisecond},
+		{Team: "Test Team 2", AccountID: "000000000002", Region: "us-east-1", APICalls: 1, Duration: time.Second},
+		{Team: "Test Team 2", AccountID: "000000000002", Region: "us-west-2", Duration: time.Second, Error: "access denied"},
+		// every row of the account's findings was excluded by suppression rules
+		{Team: "Test Team 3", AccountID: "000000000003", Region: "us-east-1", Findings: 2, APICalls: 1, Duration: 500 * time.Millisecond},
+	}
+	for _, job := range jobs {
+		builder.AddJob(job)
+	}
+
+	actual := builder.Summary()
+	expectedAccounts := []AccountStats{
+		{AccountID: "000000000001", Team: "Test Team 1", Regions: 2, Findings: 1, Rows: 1, APICalls: 2, DurationSeconds: 2},
+		{AccountID: "000000000002", Team: "Test Team 2", Regions: 2, APICalls: 1, DurationSeconds: 2, Errors: []string{"us-west-2: access denied"}},
+		{AccountID: "000000000003", Team: "Test Team 3", Regions: 1, Findings: 2, APICalls: 1, DurationSeconds: 0.5},
+	}
+	if diff := cmp.Diff(expectedAccounts, actual.Accounts); diff != "" {
+		t.Errorf("ERROR: account stats mismatch (-expected +actual):\n%s", diff)
+	}
+	if actual.APICalls != 4 {
+		t.Errorf("ERROR: expected 4 API calls, got %d", actual.APICalls)
+	}
+	if diff := cmp.Diff([]string{"000000000002"}, actual.ZeroFindingAccounts); diff != "" {
+		t.Errorf("ERROR: zero finding accounts mismatch (-expected +actual):\n%s", diff)
+	}
+
+	var b bytes.Buffer
+	err := actual.WriteText(&b)
+	if err != nil {
+		t.Fatalf("could not write summary text: %s", err)
+	}
+	if !strings.Contains(b.String(), "ACCOUNTS WITH ZERO FINDINGS\n000000000002\n") {
+		t.Errorf("ERROR: expected zero finding accounts in summary text:\n%s", b.String())
+	}
+}
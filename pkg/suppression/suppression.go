@@ -0,0 +1,207 @@
+package suppression
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Actions taken on the rows a rule matches
+const (
+	// ActionTag keeps matching rows, tagged with the Accepted Risk status and the rule ID
+	ActionTag = "tag"
+	// ActionExclude leaves matching rows out of the output
+	ActionExclude = "exclude"
+)
+
+// Actions lists the supported actions
+var Actions = []string{ActionTag, ActionExclude}
+
+// StatusAcceptedRisk is the risk status of rows matched by a rule with ActionTag
+const StatusAcceptedRisk = "Accepted Risk"
+
+// expiresLayout is the layout of rule expiry dates
+const expiresLayout = "2006-01-02"
+
+// Rules is a struct describing the format we expect in the JSON file describing suppression
+// rules for accepted risks
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a single accepted risk. A row matches the rule if it matches every criterion that is
+// set; lists match if any of their values do. The rule applies through its expiry date, after
+// which matching rows are reported as usual.
+type Rule struct {
+	ID            string `json:"id"`
+	Owner         string `json:"owner"`
+	Justification string `json:"justification"`
+	Ticket        string `json:"ticket"`
+	// Expires is the last date, as YYYY-MM-DD in UTC, that the rule applies on
+	Expires string `json:"expires"`
+	// Action is one of Actions. It defaults to ActionTag.
+	Action string `json:"action"`
+
+	Accounts   []string `json:"accounts"`
+	Teams      []string `json:"teams"`
+	ControlIDs []string `json:"controlIds"`
+	// ResourceARNs are glob patterns, e.g. arn:aws:s3:::*-logs
+	ResourceARNs []string `json:"resourceArns"`
+	Products     []string `json:"products"`
+	// Title is a regular expression matched against the finding's title
+	Title string `json:"title"`
+
+	expires time.Time
+	title   *regexp.Regexp
+}
+
+// Row is the fields of a row that rules match on
+type Row struct {
+	AccountID   string
+	Team        string
+	ControlID   string
+	ResourceARN string
+	Product     string
+	Title       string
+}
+
+// ParseRulesFile reads and validates a JSON file describing suppression rules
+func ParseRulesFile(path string) (*Rules, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading suppression rules file: %s", err)
+	}
+
+	var rules Rules
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&rules)
+	if err != nil {
+		return nil, fmt.Errorf("error JSON decoding suppression rules: %s", err)
+	}
+
+	err = rules.compile()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing suppression rules: %w", err)
+	}
+
+	return &rules, nil
+}
+
+// compile validates the rules and parses their expiry dates, patterns and regular expressions.
+// Every rule must have a unique ID, an owner, a justification, an expiry date and at least one
+// match criterion.
+func (r *Rules) compile() error {
+	var seen []string
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.ID == "" {
+			return fmt.Errorf("suppression rule %d is missing an ID", i+1)
+		}
+		if slices.Contains(seen, rule.ID) {
+			return fmt.Errorf("duplicate suppression rule ID: %s", rule.ID)
+		}
+		seen = append(seen, rule.ID)
+
+		if rule.Owner == "" || rule.Justification == "" {
+			return fmt.Errorf("suppression rule %s needs an owner and a justification", rule.ID)
+		}
+		expires, err := time.Parse(expiresLayout, rule.Expires)
+		if err != nil {
+			return fmt.Errorf("suppression rule %s has an invalid expiry date %q; expected YYYY-MM-DD", rule.ID, rule.Expires)
+		}
+		rule.expires = expires
+
+		if rule.Action == "" {
+			rule.Action = ActionTag
+		}
+		if !slices.Contains(Actions, rule.Action) {
+			return fmt.Errorf("suppression rule %s has an unknown action %q; expected one of %s", rule.ID, rule.Action, strings.Join(Actions, ", "))
+		}
+
+		for _, pattern := range rule.ResourceARNs {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("suppression rule %s has an invalid resource ARN pattern %q: %s", rule.ID, pattern, err)
+			}
+		}
+		if rule.Title != "" {
+			rule.title, err = regexp.Compile(rule.Title)
+			if err != nil {
+				return fmt.Errorf("suppression rule %s has an invalid title regular expression: %s", rule.ID, err)
+			}
+		}
+
+		if len(rule.Accounts)+len(rule.Teams)+len(rule.ControlIDs)+len(rule.ResourceARNs)+len(rule.Products) == 0 && rule.title == nil {
+			return fmt.Errorf("suppression rule %s doesn't match on anything", rule.ID)
+		}
+	}
+	return nil
+}
+
+// Expired returns true if the rule no longer applies at now
+func (r Rule) Expired(now time.Time) bool {
+	return !now.UTC().Before(r.expires.AddDate(0, 0, 1))
+}
+
+// Matches returns true if the row matches every criterion of the rule that is set
+func (r Rule) Matches(row Row) bool {
+	return matchesAny(r.Accounts, row.AccountID, strings.EqualFold) &&
+		matchesAny(r.Teams, row.Team, strings.EqualFold) &&
+		matchesAny(r.ControlIDs, row.ControlID, strings.EqualFold) &&
+		matchesAny(r.ResourceARNs, row.ResourceARN, globMatch) &&
+		matchesAny(r.Products, row.Product, strings.EqualFold) &&
+		(r.title == nil || r.title.MatchString(row.Title))
+}
+
+// Match returns the first rule that applies at now and matches the row, or nil if there is none
+func (r *Rules) Match(row Row, now time.Time) *Rule {
+	if r == nil {
+		return nil
+	}
+	for i, rule := range r.Rules {
+		if !rule.Expired(now) && rule.Matches(row) {
+			return &r.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Expired returns the rules that no longer apply at now, so they can be renewed or removed
+func (r *Rules) Expired(now time.Time) []Rule {
+	if r == nil {
+		return nil
+	}
+	var expired []Rule
+	for _, rule := range r.Rules {
+		if rule.Expired(now) {
+			expired = append(expired, rule)
+		}
+	}
+	return expired
+}
+
+// matchesAny returns true if there are no values or one of them matches value
+func matchesAny(values []string, value string, match func(string, string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if match(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch returns true if value matches the glob pattern
+func globMatch(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
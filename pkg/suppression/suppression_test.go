@@ -0,0 +1,119 @@
+package suppression
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRulesFile(t *testing.T) {
+	rules, err := ParseRulesFile("suppression_rules_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid suppression rules file: %s", err)
+	}
+	if len(rules.Rules) != 2 || rules.Rules[1].Action != ActionTag {
+		t.Errorf("ERROR: unexpected suppression rules: %+v", rules.Rules)
+	}
+
+	// this test checks that an invalid title regular expression is caught
+	_, err = ParseRulesFile("suppression_rules_test_invalid_regexp.json")
+	if err == nil {
+		t.Error("ERROR: didn't get expected error for invalid title regular expression")
+	}
+}
+
+func TestCompileRules(t *testing.T) {
+	valid := Rule{ID: "AR-001", Owner: "owner", Justification: "why", Expires: "2023-06-30", Teams: []string{"team"}}
+	testCases := []struct {
+		name string
+		edit func(*Rule)
+	}{
+		{"missing ID", func(r *Rule) { r.ID = "" }},
+		{"missing owner", func(r *Rule) { r.Owner = "" }},
+		{"invalid expiry", func(r *Rule) { r.Expires = "06/30/2023" }},
+		{"unknown action", func(r *Rule) { r.Action = "hide" }},
+		{"invalid ARN pattern", func(r *Rule) { r.ResourceARNs = []string{"arn:aws:s3:::["} }},
+		{"no criteria", func(r *Rule) { r.Teams = nil }},
+	}
+	for _, tc := range testCases {
+		rule := valid
+		tc.edit(&rule)
+		rules := Rules{Rules: []Rule{rule}}
+		if err := rules.compile(); err == nil {
+			t.Errorf("ERROR: expected an error for a rule with %s", tc.name)
+		}
+	}
+
+	rules := Rules{Rules: []Rule{valid, valid}}
+	if err := rules.compile(); err == nil {
+		t.Error("ERROR: expected an error for duplicate rule IDs")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules, err := ParseRulesFile("suppression_rules_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid suppression rules file: %s", err)
+	}
+	now := time.Date(2023, 3, 31, 23, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		row      Row
+		now      time.Time
+		expected string
+	}{
+		{
+			name:     "bucket",
+			row:      Row{AccountID: "000000000001", ControlID: "s3.2", ResourceARN: "arn:aws:s3:::access-logs"},
+			now:      now,
+			expected: "AR-001",
+		},
+		{
+			name: "other bucket",
+			row:  Row{AccountID: "000000000001", ControlID: "S3.2", ResourceARN: "arn:aws:s3:::data"},
+			now:  now,
+		},
+		{
+			name: "other account",
+			row:  Row{AccountID: "000000000002", ControlID: "S3.2", ResourceARN: "arn:aws:s3:::access-logs"},
+			now:  now,
+		},
+		{
+			name:     "title",
+			row:      Row{Team: "test team 2", Product: "Inspector", Title: "CVE-2022-1234 - openssl"},
+			now:      now,
+			expected: "AR-002",
+		},
+		{
+			name: "title mismatch",
+			row:  Row{Team: "Test Team 2", Product: "Inspector", Title: "CVE-2023-1234 - openssl"},
+			now:  now,
+		},
+		{
+			name: "expired",
+			row:  Row{Team: "Test Team 2", Product: "Inspector", Title: "CVE-2022-1234 - openssl"},
+			now:  now.Add(time.Hour),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := ""
+			if rule := rules.Match(tc.row, tc.now); rule != nil {
+				actual = rule.ID
+			}
+			if actual != tc.expected {
+				t.Errorf("ERROR: expected rule %q to match, got %q", tc.expected, actual)
+			}
+		})
+	}
+
+	expired := rules.Expired(now.Add(time.Hour))
+	if len(expired) != 1 || expired[0].ID != "AR-002" {
+		t.Errorf("ERROR: expected AR-002 to have expired, got %+v", expired)
+	}
+
+	var noRules *Rules
+	if noRules.Match(Row{}, now) != nil || noRules.Expired(now) != nil {
+		t.Error("ERROR: expected nil rules to match nothing")
+	}
+}
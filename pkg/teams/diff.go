@@ -0,0 +1,177 @@
+package teams
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// AccountEntry is the team data for a single account on one side of a diff
+type AccountEntry struct {
+	Team        string `json:"team"`
+	Environment string `json:"environment"`
+	RoleARN     string `json:"roleArn"`
+	ExternalID  string `json:"externalId,omitempty"`
+}
+
+// AccountDiff describes how a single account differs between two team maps
+type AccountDiff struct {
+	AccountID     string        `json:"accountId"`
+	From          *AccountEntry `json:"from,omitempty"`
+	To            *AccountEntry `json:"to,omitempty"`
+	ChangedFields []string      `json:"changedFields,omitempty"`
+}
+
+// TeamMapDiff is the set of differences between two maps of Accounts to team names.
+// An account that moved between teams is listed in Moved even if other fields also changed.
+type TeamMapDiff struct {
+	Added   []AccountDiff `json:"added"`
+	Removed []AccountDiff `json:"removed"`
+	Moved   []AccountDiff `json:"moved"`
+	Changed []AccountDiff `json:"changed"`
+}
+
+// Count returns the total number of differing accounts
+func (d TeamMapDiff) Count() int {
+	return len(d.Added) + len(d.Removed) + len(d.Moved) + len(d.Changed)
+}
+
+// DiffTeamMaps compares two maps of Accounts to team names by account ID
+func DiffTeamMaps(from, to map[Account]string) TeamMapDiff {
+	fromEntries := entriesByAccountID(from)
+	toEntries := entriesByAccountID(to)
+
+	diff := TeamMapDiff{
+		Added:   []AccountDiff{},
+		Removed: []AccountDiff{},
+		Moved:   []AccountDiff{},
+		Changed: []AccountDiff{},
+	}
+
+	for _, id := range sortedKeys(fromEntries) {
+		fromEntry := fromEntries[id]
+		toEntry, ok := toEntries[id]
+		if !ok {
+			diff.Removed = append(diff.Removed, AccountDiff{AccountID: id, From: &fromEntry})
+			continue
+		}
+
+		changed := changedFields(fromEntry, toEntry)
+		if len(changed) == 0 {
+			continue
+		}
+		accountDiff := AccountDiff{AccountID: id, From: &fromEntry, To: &toEntry, ChangedFields: changed}
+		if fromEntry.Team != toEntry.Team {
+			diff.Moved = append(diff.Moved, accountDiff)
+		} else {
+			diff.Changed = append(diff.Changed, accountDiff)
+		}
+	}
+
+	for _, id := range sortedKeys(toEntries) {
+		if _, ok := fromEntries[id]; !ok {
+			toEntry := toEntries[id]
+			diff.Added = append(diff.Added, AccountDiff{AccountID: id, To: &toEntry})
+		}
+	}
+
+	return diff
+}
+
+// WriteText writes a human-readable version of the diff
+func (d TeamMapDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	for _, a := range d.Added {
+		fmt.Fprintf(&b, "+ %s added to %q (environment %q, role %s)\n", a.AccountID, a.To.Team, a.To.Environment, a.To.RoleARN)
+	}
+	for _, a := range d.Removed {
+		fmt.Fprintf(&b, "- %s removed from %q (environment %q, role %s)\n", a.AccountID, a.From.Team, a.From.Environment, a.From.RoleARN)
+	}
+	for _, a := range d.Moved {
+		fmt.Fprintf(&b, "> %s moved from %q to %q%s\n", a.AccountID, a.From.Team, a.To.Team, describeFieldChanges(a, "team"))
+	}
+	for _, a := range d.Changed {
+		fmt.Fprintf(&b, "~ %s in %q changed%s\n", a.AccountID, a.To.Team, describeFieldChanges(a, ""))
+	}
+
+	fmt.Fprintf(&b, "%d added, %d removed, %d moved, %d changed\n", len(d.Added), len(d.Removed), len(d.Moved), len(d.Changed))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describeFieldChanges lists the changed fields of an account, other than skip
+func describeFieldChanges(a AccountDiff, skip string) string {
+	var parts []string
+	for _, field := range a.ChangedFields {
+		if field == skip {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %q -> %q", field, fieldValue(*a.From, field), fieldValue(*a.To, field)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(parts, ", ")
+}
+
+// entriesByAccountID re-keys a map of Accounts to team names by account ID
+func entriesByAccountID(accountsToTeams map[Account]string) map[string]AccountEntry {
+	entries := make(map[string]AccountEntry, len(accountsToTeams))
+	for account, teamName := range accountsToTeams {
+		entries[account.ID] = accountEntry(account, teamName)
+	}
+	return entries
+}
+
+// accountEntry returns the team data for an account
+func accountEntry(account Account, teamName string) AccountEntry {
+	return AccountEntry{
+		Team:        teamName,
+		Environment: account.Environment,
+		RoleARN:     account.RoleARN,
+		ExternalID:  account.ExternalID,
+	}
+}
+
+// diffFields are the AccountEntry fields compared by DiffTeamMaps, in reporting order
+var diffFields = []string{"team", "environment", "roleArn", "externalId"}
+
+// changedFields returns the names of the fields that differ between two entries
+func changedFields(from, to AccountEntry) []string {
+	var changed []string
+	for _, field := range diffFields {
+		if fieldValue(from, field) != fieldValue(to, field) {
+			changed = append(changed, field)
+		}
+	}
+	return changed
+}
+
+// fieldValue returns the value of a named AccountEntry field
+func fieldValue(e AccountEntry, field string) string {
+	switch field {
+	case "team":
+		return e.Team
+	case "environment":
+		return e.Environment
+	case "roleArn":
+		return e.RoleARN
+	case "externalId":
+		return e.ExternalID
+	default:
+		return ""
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package teams
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffTeamMaps(t *testing.T) {
+	from, err := ParseTeamMapFile("team_map_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid team map file: %s", err)
+	}
+	to, err := ParseTeamMapFile("team_map_test_changed.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse changed team map file: %s", err)
+	}
+
+	expected := TeamMapDiff{
+		Added: []AccountDiff{
+			{
+				AccountID: "account 3",
+				To:        &AccountEntry{Team: "Test Team 2", Environment: "dev", RoleARN: "arn:aws:iam::000000000015:role/CustomRole"},
+			},
+		},
+		Removed: []AccountDiff{
+			{
+				AccountID: "account 22",
+				From:      &AccountEntry{Team: "Test Team 2", Environment: "prod", RoleARN: "arn:aws:iam::000000000014:role/CustomRole"},
+			},
+		},
+		Moved: []AccountDiff{
+			{
+				AccountID:     "account 11",
+				From:          &AccountEntry{Team: "Test Team 1", Environment: "test", RoleARN: "arn:aws:iam::000000000012:role/CustomRole"},
+				To:            &AccountEntry{Team: "Test Team 2", Environment: "test", RoleARN: "arn:aws:iam::000000000012:role/CustomRole"},
+				ChangedFields: []string{"team"},
+			},
+			{
+				AccountID:     "account 2",
+				From:          &AccountEntry{Team: "Test Team 2", Environment: "impl", RoleARN: "arn:aws:iam::000000000013:role/CustomRole"},
+				To:            &AccountEntry{Team: "Test Team 3", Environment: "impl", RoleARN: "arn:aws:iam::000000000013:role/OtherRole"},
+				ChangedFields: []string{"team", "roleArn"},
+			},
+		},
+		Changed: []AccountDiff{
+			{
+				AccountID:     "account 1",
+				From:          &AccountEntry{Team: "Test Team 1", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"},
+				To:            &AccountEntry{Team: "Test Team 1", Environment: "test", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"},
+				ChangedFields: []string{"environment"},
+			},
+		},
+	}
+
+	actual := DiffTeamMaps(from, to)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("Expected team map diff did not match actual: %s", diff)
+	}
+	if actual.Count() != 5 {
+		t.Errorf("ERROR: expected 5 differences, got %d", actual.Count())
+	}
+
+	expectedText := `+ account 3 added to "Test Team 2" (environment "dev", role arn:aws:iam::000000000015:role/CustomRole)
+- account 22 removed from "Test Team 2" (environment "prod", role arn:aws:iam::000000000014:role/CustomRole)
+> account 11 moved from "Test Team 1" to "Test Team 2"
+> account 2 moved from "Test Team 2" to "Test Team 3": roleArn "arn:aws:iam::000000000013:role/CustomRole" -> "arn:aws:iam::000000000013:role/OtherRole"
+~ account 1 in "Test Team 1" changed: environment "dev" -> "test"
+1 added, 1 removed, 2 moved, 1 changed
+`
+	var b bytes.Buffer
+	err = actual.WriteText(&b)
+	if err != nil {
+		t.Fatalf("ERROR: could not write diff text: %s", err)
+	}
+	if diff := cmp.Diff(expectedText, b.String()); diff != "" {
+		t.Errorf("Expected diff text did not match actual: %s", diff)
+	}
+
+	// identical maps have no differences
+	if count := DiffTeamMaps(from, from).Count(); count != 0 {
+		t.Errorf("ERROR: expected no differences between identical maps, got %d", count)
+	}
+}
@@ -0,0 +1,170 @@
+package teams
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modes for merging a team source into the sources before it
+const (
+	// MergeFirstWins adds accounts that earlier sources don't have; earlier sources win conflicts
+	MergeFirstWins = "first-wins"
+	// MergeOverride adds accounts that earlier sources don't have; this source wins conflicts
+	MergeOverride = "override"
+	// MergeUnion adds accounts that earlier sources don't have; any conflict is an error
+	MergeUnion = "union"
+)
+
+type mergeConflictError struct {
+	message string
+}
+
+func (e *mergeConflictError) Error() string {
+	return e.message
+}
+
+// MergeSource is a team source and the mode used to merge it with the sources before it.
+// Merge sources are written as "[<mode>=]<source>", e.g. "override=file:fixes.json".
+type MergeSource struct {
+	Source Source
+	Mode   string
+}
+
+// ParseMergeSource parses a merge source written as "[<mode>=]<source>". The mode defaults to first-wins.
+func ParseMergeSource(spec string) (MergeSource, error) {
+	mode := MergeFirstWins
+	sourceSpec := spec
+	if before, after, found := strings.Cut(spec, "="); found && !strings.Contains(before, ":") {
+		mode = before
+		sourceSpec = after
+	}
+
+	switch mode {
+	case MergeFirstWins, MergeOverride, MergeUnion:
+	default:
+		return MergeSource{}, fmt.Errorf("unknown merge mode %q in %q; expected one of %s, %s, %s", mode, spec, MergeFirstWins, MergeOverride, MergeUnion)
+	}
+
+	source, err := ParseSource(sourceSpec)
+	if err != nil {
+		return MergeSource{}, err
+	}
+	return MergeSource{Source: source, Mode: mode}, nil
+}
+
+// String returns the merge source in the form accepted by ParseMergeSource
+func (m MergeSource) String() string {
+	return m.Mode + "=" + m.Source.String()
+}
+
+// MergeLayer is a loaded team map to merge, along with its name and merge mode
+type MergeLayer struct {
+	Name            string
+	Mode            string
+	AccountsToTeams map[Account]string
+}
+
+// Conflict describes an account whose mapping differs between two merged sources
+type Conflict struct {
+	AccountID      string       `json:"accountId"`
+	ExistingSource string       `json:"existingSource"`
+	IncomingSource string       `json:"incomingSource"`
+	Existing       AccountEntry `json:"existing"`
+	Incoming       AccountEntry `json:"incoming"`
+	// Kept is the name of the source whose mapping was kept
+	Kept string `json:"kept"`
+}
+
+// String describes the conflict for logging
+func (c Conflict) String() string {
+	return fmt.Sprintf("account %s is mapped to %q by %s and %q by %s (differs in %s); kept %s",
+		c.AccountID, c.Existing.Team, c.ExistingSource, c.Incoming.Team, c.IncomingSource,
+		strings.Join(changedFields(c.Existing, c.Incoming), ", "), c.Kept)
+}
+
+// MergeResult is the merged map of Accounts to team names along with any conflicts found.
+// The Source of every Account records the name of the source its mapping came from.
+type MergeResult struct {
+	AccountsToTeams map[Account]string
+	Conflicts       []Conflict
+}
+
+// LoadAndMerge loads each source in order and merges them
+func LoadAndMerge(sources []MergeSource, cfg SourceConfig) (*MergeResult, error) {
+	layers := make([]MergeLayer, 0, len(sources))
+	for _, source := range sources {
+		accountsToTeams, err := source.Source.Load(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not load team source %s: %w", source.Source, err)
+		}
+		layers = append(layers, MergeLayer{Name: source.Source.String(), Mode: source.Mode, AccountsToTeams: accountsToTeams})
+	}
+	return Merge(layers)
+}
+
+// Merge combines team maps in order. The first layer is the base; each later layer is merged into
+// the result according to its mode. Accounts are matched by ID, and an account whose team,
+// environment, role ARN or external ID differs between layers is reported as a conflict.
+func Merge(layers []MergeLayer) (*MergeResult, error) {
+	merged := make(map[string]mapping)
+	var conflicts []Conflict
+
+	for _, layer := range layers {
+		layerMappings := mappingsByAccountID(layer.AccountsToTeams)
+		for _, id := range sortedKeys(layerMappings) {
+			account, teamName := layerMappings[id].account, layerMappings[id].teamName
+			account.Source = layer.Name
+
+			existing, ok := merged[id]
+			if !ok {
+				merged[id] = mapping{account: account, teamName: teamName}
+				continue
+			}
+
+			existingEntry := accountEntry(existing.account, existing.teamName)
+			incomingEntry := accountEntry(account, teamName)
+			if len(changedFields(existingEntry, incomingEntry)) == 0 {
+				continue
+			}
+
+			conflict := Conflict{
+				AccountID:      id,
+				ExistingSource: existing.account.Source,
+				IncomingSource: layer.Name,
+				Existing:       existingEntry,
+				Incoming:       incomingEntry,
+				Kept:           existing.account.Source,
+			}
+
+			switch layer.Mode {
+			case MergeOverride:
+				merged[id] = mapping{account: account, teamName: teamName}
+				conflict.Kept = layer.Name
+			case MergeUnion:
+				return nil, &mergeConflictError{message: "conflicting team sources: " + conflict.String()}
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	result := &MergeResult{AccountsToTeams: make(map[Account]string, len(merged)), Conflicts: conflicts}
+	for _, m := range merged {
+		result.AccountsToTeams[m.account] = m.teamName
+	}
+	return result, nil
+}
+
+// mapping is a single account's entry in a map of Accounts to team names
+type mapping struct {
+	account  Account
+	teamName string
+}
+
+// mappingsByAccountID re-keys a map of Accounts to team names by account ID
+func mappingsByAccountID(accountsToTeams map[Account]string) map[string]mapping {
+	mappings := make(map[string]mapping, len(accountsToTeams))
+	for account, teamName := range accountsToTeams {
+		mappings[account.ID] = mapping{account: account, teamName: teamName}
+	}
+	return mappings
+}
@@ -0,0 +1,91 @@
+package teams
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var supplementalAccountsToTeams = map[Account]string{
+	// conflicts with the base map: different team
+	{ID: "account 1", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"}: "Test Team 3",
+	// identical to the base map, so not a conflict
+	{ID: "account 2", Environment: "impl", RoleARN: "arn:aws:iam::000000000013:role/CustomRole"}: "Test Team 2",
+	// only in the supplemental map
+	{ID: "account 3", Environment: "prod", RoleARN: "arn:aws:iam::000000000015:role/CustomRole"}: "Test Team 3",
+}
+
+func TestParseMergeSource(t *testing.T) {
+	testCases := []struct {
+		spec      string
+		expected  MergeSource
+		expectErr bool
+	}{
+		{spec: "teams-api", expected: MergeSource{Source: Source{Kind: SourceTeamsAPI}, Mode: MergeFirstWins}},
+		{spec: "override=file:fixes.json", expected: MergeSource{Source: Source{Kind: SourceFile, Location: "fixes.json"}, Mode: MergeOverride}},
+		{spec: "union=file:extra=accounts.json", expected: MergeSource{Source: Source{Kind: SourceFile, Location: "extra=accounts.json"}, Mode: MergeUnion}},
+		{spec: "file:a=b.json", expected: MergeSource{Source: Source{Kind: SourceFile, Location: "a=b.json"}, Mode: MergeFirstWins}},
+		{spec: "replace=teams-api", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			actual, err := ParseMergeSource(tc.spec)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("ERROR: expected an error parsing %q", tc.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ERROR: could not parse merge source %q: %s", tc.spec, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("ERROR: expected merge source %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := MergeLayer{Name: "teams-api", Mode: MergeFirstWins, AccountsToTeams: expectedAccountsToTeams}
+
+	// first-wins keeps the base mapping for account 1 and adds account 3
+	result, err := Merge([]MergeLayer{base, {Name: "file:supplemental.json", Mode: MergeFirstWins, AccountsToTeams: supplementalAccountsToTeams}})
+	if err != nil {
+		t.Fatalf("ERROR: could not merge team maps: %s", err)
+	}
+	expected := map[Account]string{
+		{ID: "account 1", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/CustomRole", Source: "teams-api"}:               "Test Team 1",
+		{ID: "account 11", Environment: "test", RoleARN: "arn:aws:iam::000000000012:role/CustomRole", Source: "teams-api"}:             "Test Team 1",
+		{ID: "account 2", Environment: "impl", RoleARN: "arn:aws:iam::000000000013:role/CustomRole", Source: "teams-api"}:              "Test Team 2",
+		{ID: "account 22", Environment: "prod", RoleARN: "arn:aws:iam::000000000014:role/CustomRole", Source: "teams-api"}:             "Test Team 2",
+		{ID: "account 3", Environment: "prod", RoleARN: "arn:aws:iam::000000000015:role/CustomRole", Source: "file:supplemental.json"}: "Test Team 3",
+	}
+	if !reflect.DeepEqual(expected, result.AccountsToTeams) {
+		t.Errorf("ERROR: expected first-wins merge does not match actual. Expected: %#v, Actual: %#v", expected, result.AccountsToTeams)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].AccountID != "account 1" || result.Conflicts[0].Kept != "teams-api" {
+		t.Errorf("ERROR: expected one conflict for account 1 resolved in favor of teams-api, got %#v", result.Conflicts)
+	}
+
+	// override replaces the base mapping for account 1
+	result, err = Merge([]MergeLayer{base, {Name: "file:supplemental.json", Mode: MergeOverride, AccountsToTeams: supplementalAccountsToTeams}})
+	if err != nil {
+		t.Fatalf("ERROR: could not merge team maps: %s", err)
+	}
+	overridden := Account{ID: "account 1", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/CustomRole", Source: "file:supplemental.json"}
+	if result.AccountsToTeams[overridden] != "Test Team 3" {
+		t.Errorf("ERROR: expected account 1 to be overridden to Test Team 3, got %#v", result.AccountsToTeams)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Kept != "file:supplemental.json" {
+		t.Errorf("ERROR: expected one conflict resolved in favor of the override, got %#v", result.Conflicts)
+	}
+
+	// union fails on conflicts
+	_, err = Merge([]MergeLayer{base, {Name: "file:supplemental.json", Mode: MergeUnion, AccountsToTeams: supplementalAccountsToTeams}})
+	var mergeConflictError *mergeConflictError
+	if err == nil || !errors.As(err, &mergeConflictError) {
+		t.Error("ERROR: didn't get expected error for conflicting union merge", err)
+	}
+}
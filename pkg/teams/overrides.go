@@ -0,0 +1,177 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
+	awsclient "github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+)
+
+type invalidOverrideError struct {
+	message string
+}
+
+func (e *invalidOverrideError) Error() string {
+	return e.message
+}
+
+// AccountOverrides is a struct describing the format we expect in the JSON file
+// describing per-account overrides
+type AccountOverrides struct {
+	Accounts []AccountOverride `json:"accounts"`
+}
+
+// AccountOverride describes settings for a single account that replace the values
+// derived from the team source. Empty fields leave the account's values unchanged.
+type AccountOverride struct {
+	ID         string   `json:"id"`
+	RoleName   string   `json:"roleName"`
+	Partition  string   `json:"partition"`
+	ExternalID string   `json:"externalId"`
+	Regions    []string `json:"regions"`
+}
+
+// ParseAccountOverridesFile reads and validates a JSON file of per-account overrides
+func ParseAccountOverridesFile(path string) (*AccountOverrides, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading account overrides file: %s", err)
+	}
+
+	var overrides AccountOverrides
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&overrides)
+	if err != nil {
+		return nil, fmt.Errorf("error JSON decoding account overrides: %s", err)
+	}
+
+	err = overrides.validate()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing account overrides: %w", err)
+	}
+
+	return &overrides, nil
+}
+
+// validate checks that every override has an ID, is listed once, and names a known
+// partition whose regions match its preferred regions
+func (o *AccountOverrides) validate() error {
+	var seen []string
+	for _, override := range o.Accounts {
+		if override.ID == "" {
+			return &invalidOverrideError{message: "account override is missing an account ID"}
+		}
+		if slices.Contains(seen, override.ID) {
+			return &duplicateAccountIDError{
+				message: fmt.Sprintf("duplicate account ID in account overrides: %s", override.ID),
+			}
+		}
+		seen = append(seen, override.ID)
+
+		if override.Partition != "" && !awsclient.IsKnownPartition(override.Partition) {
+			return &invalidOverrideError{
+				message: fmt.Sprintf("unknown partition for account %s: %s", override.ID, override.Partition),
+			}
+		}
+
+		if override.Partition != "" {
+			for _, region := range override.Regions {
+				if awsclient.RegionPartition(region) != override.Partition {
+					return &invalidOverrideError{
+						message: fmt.Sprintf("region %s for account %s is not in partition %s", region, override.ID, override.Partition),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the override for an account ID, if there is one
+func (o *AccountOverrides) lookup(accountID string) (AccountOverride, bool) {
+	if o == nil {
+		return AccountOverride{}, false
+	}
+	for _, override := range o.Accounts {
+		if override.ID == accountID {
+			return override, true
+		}
+	}
+	return AccountOverride{}, false
+}
+
+// Apply returns a copy of the map of Accounts to team names with the overrides layered on top.
+// The role ARN is rebuilt from the overridden partition and role name, keeping the
+// original value of whichever one isn't overridden, and the account ID of the original ARN.
+func (o *AccountOverrides) Apply(accountsToTeams map[Account]string) (map[Account]string, error) {
+	result := make(map[Account]string, len(accountsToTeams))
+	for account, teamName := range accountsToTeams {
+		override, ok := o.lookup(account.ID)
+		if !ok {
+			result[account] = teamName
+			continue
+		}
+
+		if override.Partition != "" || override.RoleName != "" {
+			parsed, err := arn.Parse(account.RoleARN)
+			if err != nil {
+				return nil, &invalidRoleARNError{
+					message: fmt.Sprintf("invalid role ARN for account %s: %s", account.ID, account.RoleARN),
+				}
+			}
+
+			partition := parsed.Partition
+			if override.Partition != "" {
+				partition = override.Partition
+			}
+			roleName := strings.TrimPrefix(parsed.Resource, "role/")
+			if override.RoleName != "" {
+				roleName = override.RoleName
+			}
+			account.RoleARN = BuildRoleARN(partition, parsed.AccountID, roleName)
+		}
+
+		if override.ExternalID != "" {
+			account.ExternalID = override.ExternalID
+		}
+
+		result[account] = teamName
+	}
+	return result, nil
+}
+
+// RegionsFor returns the Security Hub regions to collect from for an account. An override's
+// preferred regions win. Commercial accounts without an override use the default regions as
+// they are; otherwise the default regions in the account's partition are used, falling back to
+// the partition's default region if none of them are in that partition.
+func (o *AccountOverrides) RegionsFor(account Account, defaultRegions []string) []string {
+	override, ok := o.lookup(account.ID)
+	if ok && len(override.Regions) > 0 {
+		return override.Regions
+	}
+
+	partition := account.Partition()
+	if !ok && partition == awsclient.PartitionAWS {
+		return defaultRegions
+	}
+	var regions []string
+	for _, region := range defaultRegions {
+		if awsclient.RegionPartition(region) == partition {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		if region := awsclient.PartitionDefaultRegion(partition); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
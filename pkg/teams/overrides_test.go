@@ -0,0 +1,116 @@
+package teams
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseAccountOverridesFile(t *testing.T) {
+	overrides, err := ParseAccountOverridesFile("account_overrides_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid account overrides file: %s", err)
+	}
+	if len(overrides.Accounts) != 2 {
+		t.Errorf("ERROR: expected 2 account overrides, got %d", len(overrides.Accounts))
+	}
+
+	// this test checks that a duplicate account ID is caught
+	_, err = ParseAccountOverridesFile("account_overrides_test_duplicate.json")
+	var duplicateAccountIDError *duplicateAccountIDError
+	if err == nil || !errors.As(err, &duplicateAccountIDError) {
+		t.Error("ERROR: didn't get expected error for duplicate account ID", err)
+	}
+
+	// this test checks that a region outside the override's partition is caught
+	_, err = ParseAccountOverridesFile("account_overrides_test_invalid_region.json")
+	var invalidOverrideError *invalidOverrideError
+	if err == nil || !errors.As(err, &invalidOverrideError) {
+		t.Error("ERROR: didn't get expected error for region outside partition", err)
+	}
+}
+
+func TestApplyAccountOverrides(t *testing.T) {
+	overrides, err := ParseAccountOverridesFile("account_overrides_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid account overrides file: %s", err)
+	}
+
+	expected := map[Account]string{
+		{ID: "account 1", Environment: "dev", RoleARN: "arn:aws:iam::000000000011:role/OtherRole"}:                                       "Test Team 1",
+		{ID: "account 11", Environment: "test", RoleARN: "arn:aws:iam::000000000012:role/CustomRole"}:                                    "Test Team 1",
+		{ID: "account 2", Environment: "impl", RoleARN: "arn:aws-us-gov:iam::000000000013:role/CustomRole", ExternalID: "external-id-2"}: "Test Team 2",
+		{ID: "account 22", Environment: "prod", RoleARN: "arn:aws:iam::000000000014:role/CustomRole"}:                                    "Test Team 2",
+	}
+	actual, err := overrides.Apply(expectedAccountsToTeams)
+	if err != nil {
+		t.Fatalf("ERROR: could not apply account overrides: %s", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("ERROR: expected account to team map does not match actual. Expected: %#v, Actual: %#v", expected, actual)
+	}
+
+	// a nil set of overrides leaves the map unchanged
+	var noOverrides *AccountOverrides
+	actual, err = noOverrides.Apply(expectedAccountsToTeams)
+	if err != nil {
+		t.Fatalf("ERROR: could not apply nil account overrides: %s", err)
+	}
+	if !reflect.DeepEqual(expectedAccountsToTeams, actual) {
+		t.Errorf("ERROR: expected unchanged account to team map. Expected: %#v, Actual: %#v", expectedAccountsToTeams, actual)
+	}
+}
+
+func TestRegionsFor(t *testing.T) {
+	overrides, err := ParseAccountOverridesFile("account_overrides_test_valid.json")
+	if err != nil {
+		t.Fatalf("ERROR: could not parse valid account overrides file: %s", err)
+	}
+	defaultRegions := []string{"us-east-1", "us-west-2"}
+
+	testCases := []struct {
+		name     string
+		account  Account
+		expected []string
+	}{
+		{
+			name:     "override regions",
+			account:  Account{ID: "account 2", RoleARN: "arn:aws-us-gov:iam::000000000013:role/CustomRole"},
+			expected: []string{"us-gov-west-1", "us-gov-east-1"},
+		},
+		{
+			name:     "commercial account without region override",
+			account:  Account{ID: "account 1", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"},
+			expected: []string{"us-east-1", "us-west-2"},
+		},
+		{
+			name:     "GovCloud account without region override",
+			account:  Account{ID: "account 3", RoleARN: "arn:aws-us-gov:iam::000000000015:role/CustomRole"},
+			expected: []string{"us-gov-west-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := overrides.RegionsFor(tc.account, defaultRegions)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("ERROR: expected regions do not match actual. Expected: %#v, Actual: %#v", tc.expected, actual)
+			}
+		})
+	}
+
+	// commercial accounts without an override collect from every default region
+	mixedRegions := []string{"us-east-1", "us-gov-west-1"}
+	for _, o := range []*AccountOverrides{nil, overrides} {
+		actual := o.RegionsFor(Account{ID: "account 3", RoleARN: "arn:aws:iam::000000000015:role/CustomRole"}, mixedRegions)
+		if !reflect.DeepEqual(mixedRegions, actual) {
+			t.Errorf("ERROR: expected default regions to be unchanged. Expected: %#v, Actual: %#v", mixedRegions, actual)
+		}
+	}
+
+	// an override without regions still limits the account to its partition
+	actual := overrides.RegionsFor(Account{ID: "account 1", RoleARN: "arn:aws:iam::000000000011:role/CustomRole"}, mixedRegions)
+	if !reflect.DeepEqual([]string{"us-east-1"}, actual) {
+		t.Errorf("ERROR: expected regions in the account's partition. Expected: %#v, Actual: %#v", []string{"us-east-1"}, actual)
+	}
+}
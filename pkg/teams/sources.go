@@ -0,0 +1,87 @@
+package teams
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of team sources
+const (
+	// SourceTeamMap is a base64 encoded JSON team map, as passed in BASE64_TEAM_MAP
+	SourceTeamMap = "team-map"
+	// SourceFile is a path to a JSON team map file
+	SourceFile = "file"
+	// SourceTeamsAPI is the Teams API
+	SourceTeamsAPI = "teams-api"
+)
+
+// Source describes a place team data can be loaded from. Sources are written as
+// "<kind>" or "<kind>:<location>", e.g. "file:team_map.json" or "teams-api".
+type Source struct {
+	Kind     string
+	Location string
+}
+
+// SourceConfig holds the settings used to load a Source when its location is not
+// given in the source itself
+type SourceConfig struct {
+	Base64TeamMap   string
+	TeamsAPIBaseURL string
+	TeamsAPIKey     string
+	RolePath        string
+}
+
+// ParseSource parses a source written as "<kind>" or "<kind>:<location>"
+func ParseSource(spec string) (Source, error) {
+	kind, location, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch kind {
+	case SourceTeamMap, SourceTeamsAPI:
+	case SourceFile:
+		if location == "" {
+			return Source{}, fmt.Errorf("team source %q requires a file path", spec)
+		}
+	default:
+		return Source{}, fmt.Errorf("unknown team source kind %q in %q; expected one of %s, %s, %s", kind, spec, SourceTeamMap, SourceFile, SourceTeamsAPI)
+	}
+	return Source{Kind: kind, Location: location}, nil
+}
+
+// String returns the source in the form accepted by ParseSource. Base64 team maps are
+// abbreviated since they can be very long.
+func (s Source) String() string {
+	if s.Location == "" || s.Kind == SourceTeamMap {
+		return s.Kind
+	}
+	return s.Kind + ":" + s.Location
+}
+
+// Load loads the map of Accounts to team names from the source
+func (s Source) Load(cfg SourceConfig) (map[Account]string, error) {
+	switch s.Kind {
+	case SourceTeamMap:
+		teamMap := s.Location
+		if teamMap == "" {
+			teamMap = cfg.Base64TeamMap
+		}
+		if teamMap == "" {
+			return nil, fmt.Errorf("no base64 team map provided for team source %s", s)
+		}
+		return ParseTeamMap(teamMap)
+	case SourceFile:
+		return ParseTeamMapFile(s.Location)
+	case SourceTeamsAPI:
+		baseURL := s.Location
+		if baseURL == "" {
+			baseURL = cfg.TeamsAPIBaseURL
+		}
+		if baseURL == "" {
+			return nil, fmt.Errorf("no Teams API base URL provided for team source %s", s)
+		}
+		if cfg.TeamsAPIKey == "" {
+			return nil, fmt.Errorf("Teams API key required when using Teams API")
+		}
+		return GetTeamsFromTeamsAPI(baseURL, cfg.TeamsAPIKey, cfg.RolePath)
+	default:
+		return nil, fmt.Errorf("unknown team source kind %q", s.Kind)
+	}
+}
@@ -0,0 +1,66 @@
+package teams
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	testCases := []struct {
+		spec      string
+		expected  Source
+		expectErr bool
+	}{
+		{spec: "teams-api", expected: Source{Kind: SourceTeamsAPI}},
+		{spec: "teams-api:https://teams.example.com/prod", expected: Source{Kind: SourceTeamsAPI, Location: "https://teams.example.com/prod"}},
+		{spec: "file:team_map.json", expected: Source{Kind: SourceFile, Location: "team_map.json"}},
+		{spec: "team-map", expected: Source{Kind: SourceTeamMap}},
+		{spec: "file", expectErr: true},
+		{spec: "s3:bucket/key", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			actual, err := ParseSource(tc.spec)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("ERROR: expected an error parsing %q", tc.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ERROR: could not parse source %q: %s", tc.spec, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("ERROR: expected source %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadSource(t *testing.T) {
+	validStr, err := base64EncodeTestJSON("team_map_test_valid.json")
+	if err != nil {
+		t.Fatalf("failed to read valid JSON file: %s", err)
+	}
+
+	for _, spec := range []string{"file:team_map_test_valid.json", "team-map"} {
+		source, err := ParseSource(spec)
+		if err != nil {
+			t.Fatalf("ERROR: could not parse source %q: %s", spec, err)
+		}
+		actual, err := source.Load(SourceConfig{Base64TeamMap: validStr})
+		if err != nil {
+			t.Fatalf("ERROR: could not load source %q: %s", spec, err)
+		}
+		if !reflect.DeepEqual(expectedAccountsToTeams, actual) {
+			t.Errorf("ERROR: expected account to team map does not match actual for %q. Expected: %#v, Actual: %#v", spec, expectedAccountsToTeams, actual)
+		}
+	}
+
+	// the Teams API requires an API key
+	_, err = Source{Kind: SourceTeamsAPI}.Load(SourceConfig{TeamsAPIBaseURL: "https://teams.example.com"})
+	if err == nil {
+		t.Error("ERROR: expected an error loading the Teams API without an API key")
+	}
+}
@@ -2,13 +2,19 @@ package teams
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 
 	teamsapi "github.com/Enterprise-CMCS/mac-fc-teams-api/client"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
+	awsclient "github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
 )
 
 // SEATool accounts are in the Teams API team data (because we get CUR data from them)
@@ -52,15 +58,47 @@ type Account struct {
 	ID          string
 	Environment string
 	RoleARN     string
+	ExternalID  string
+	// Source is the name of the team source the account's mapping came from, when team
+	// sources are merged. It is never read from a team map.
+	Source string `json:"-"`
+}
+
+// Partition returns the AWS partition of the account's role ARN, defaulting to "aws"
+func (a Account) Partition() string {
+	parsed, err := arn.Parse(a.RoleARN)
+	if err != nil {
+		return awsclient.PartitionAWS
+	}
+	return parsed.Partition
+}
+
+// BuildRoleARN returns the ARN of the IAM role at rolePath in the given account and partition
+func BuildRoleARN(partition, accountID, rolePath string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountID, rolePath)
 }
 
 // ParseTeamMap takes a base64 encoded team map string and returns a Go map of Accounts to team names
 func ParseTeamMap(base64Str string) (accountsToTeams map[Account]string, err error) {
-	var teams Teams
 	b, err := base64.URLEncoding.DecodeString(base64Str)
 	if err != nil {
 		return nil, fmt.Errorf("error base64 decoding team map: %s", err)
 	}
+	return parseTeamMapJSON(b)
+}
+
+// ParseTeamMapFile reads a (not base64 encoded) JSON team map file and returns a Go map of Accounts to team names
+func ParseTeamMapFile(path string) (map[Account]string, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading team map file: %s", err)
+	}
+	return parseTeamMapJSON(b)
+}
+
+// parseTeamMapJSON decodes and validates a JSON team map
+func parseTeamMapJSON(b []byte) (accountsToTeams map[Account]string, err error) {
+	var teams Teams
 	decoder := json.NewDecoder(bytes.NewReader(b))
 	decoder.DisallowUnknownFields()
 	err = decoder.Decode(&teams)
@@ -109,7 +147,7 @@ func GetTeamsFromTeamsAPI(baseURL string, apiKey string, rolePath string) (map[A
 			account := Account{
 				ID:          acct.ID,
 				Environment: acct.Name, // Use the name as the environment value for compatibility with existing QuickSight dashboard
-				RoleARN:     fmt.Sprintf("arn:aws:iam::%s:role/%s", acct.ID, rolePath),
+				RoleARN:     BuildRoleARN(awsclient.PartitionAWS, acct.ID, rolePath),
 			}
 
 			accountsToTeams[account] = team.Name
@@ -119,6 +157,35 @@ func GetTeamsFromTeamsAPI(baseURL string, apiKey string, rolePath string) (map[A
 	return accountsToTeams, nil
 }
 
+// TeamNames returns the sorted, de-duplicated team names in the given map of Accounts to team names
+func TeamNames(accountsToTeams map[Account]string) []string {
+	var names []string
+	for _, name := range accountsToTeams {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SnapshotHash returns a SHA-256 hash of the given map of Accounts to team names that only changes
+// when the team data does, so that runs using the same team map can be recognized
+func SnapshotHash(accountsToTeams map[Account]string) string {
+	entries := entriesByAccountID(accountsToTeams)
+	hash := sha256.New()
+	for _, id := range sortedKeys(entries) {
+		// errors can't happen when encoding strings
+		b, _ := json.Marshal(struct {
+			ID string `json:"id"`
+			AccountEntry
+		}{id, entries[id]})
+		hash.Write(b)
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 // hasAccount checks if the given account ID is in the map of Accounts to team names
 func hasAccount(accountsToTeamNames map[Account]string, accountID string) bool {
 	for account := range accountsToTeamNames {
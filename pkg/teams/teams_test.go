@@ -67,3 +67,40 @@ func TestParseTeamMap(t *testing.T) {
 		t.Error("ERROR: didn't get expected error for invalid Role ARN", err)
 	}
 }
+
+func TestTeamNames(t *testing.T) {
+	expected := []string{"Test Team 1", "Test Team 2"}
+	actual := TeamNames(expectedAccountsToTeams)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("ERROR: expected team names do not match actual. Expected: %#v, Actual: %#v", expected, actual)
+	}
+
+	if names := TeamNames(map[Account]string{}); len(names) != 0 {
+		t.Errorf("ERROR: expected no team names for an empty map, got %#v", names)
+	}
+}
+
+func TestSnapshotHash(t *testing.T) {
+	hash := SnapshotHash(expectedAccountsToTeams)
+	if len(hash) != 64 {
+		t.Fatalf("ERROR: expected a hex SHA-256 hash, got %q", hash)
+	}
+
+	// the hash only depends on the team data, not on where it was loaded from
+	relabeled := make(map[Account]string)
+	for account, team := range expectedAccountsToTeams {
+		account.Source = "file:team_map.json"
+		relabeled[account] = team
+	}
+	if actual := SnapshotHash(relabeled); actual != hash {
+		t.Errorf("ERROR: expected the same hash for the same team data, got %s and %s", hash, actual)
+	}
+
+	moved := make(map[Account]string)
+	for account := range expectedAccountsToTeams {
+		moved[account] = "Another Team"
+	}
+	if actual := SnapshotHash(moved); actual == hash {
+		t.Errorf("ERROR: expected a different hash after moving accounts between teams")
+	}
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+)
+
+// Exporters
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ServiceName is the service.name of the collector's spans
+const ServiceName = "security-hub-collector"
+
+const instrumentationName = "github.com/Enterprise-CMCS/security-hub-collector"
+
+// Span attribute keys
+const (
+	AttrRunID      = attribute.Key("run_id")
+	AttrTeam       = attribute.Key("team")
+	AttrAccountID  = attribute.Key("account_id")
+	AttrRegion     = attribute.Key("region")
+	AttrPage       = attribute.Key("page")
+	AttrFindings   = attribute.Key("findings")
+	AttrRows       = attribute.Key("rows")
+	AttrAccounts   = attribute.Key("accounts")
+	AttrErrorClass = attribute.Key("error_class")
+)
+
+// Setup sets the global tracer provider to export spans with the given exporter: ExporterStdout
+// writes them to w as JSON, and ExporterOTLP sends them over OTLP/HTTP to the endpoint given by
+// the standard OTEL_EXPORTER_OTLP_* environment variables. With ExporterNone, spans are not
+// recorded. The returned function flushes any spans that haven't been exported yet and must be
+// called before exiting.
+func Setup(ctx context.Context, exporter, version string, w io.Writer) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not create %s trace exporter: %v", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not create trace resource: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of any span in ctx, using the global tracer provider
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, first marking it as failed if err is set
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(AttrErrorClass.String(client.ErrorClass(err)))
+	}
+	span.End()
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSetupStdout(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), ExporterStdout, "test", &buf)
+	if err != nil {
+		t.Fatalf("ERROR: could not set up tracing: %s", err)
+	}
+
+	ctx, parent := Start(context.Background(), "parent", AttrTeam.String("Test Team"))
+	_, child := Start(ctx, "child", AttrAccountID.String("000000000001"))
+	End(child, errors.New("test error"))
+	End(parent, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("ERROR: could not shut down tracing: %s", err)
+	}
+
+	type span struct {
+		Name        string
+		SpanContext struct{ SpanID string }
+		Parent      struct{ SpanID string }
+		Attributes  []struct {
+			Key   string
+			Value struct{ Value interface{} }
+		}
+		Status struct{ Code string }
+	}
+	spans := make(map[string]span)
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var s span
+		if err := decoder.Decode(&s); err != nil {
+			t.Fatalf("ERROR: could not decode span: %s", err)
+		}
+		spans[s.Name] = s
+	}
+
+	parentSpan, childSpan := spans["parent"], spans["child"]
+	if childSpan.Parent.SpanID != parentSpan.SpanContext.SpanID {
+		t.Errorf("ERROR: expected child span to have parent %s, got %s", parentSpan.SpanContext.SpanID, childSpan.Parent.SpanID)
+	}
+	if parentSpan.Status.Code != "Unset" || childSpan.Status.Code != "Error" {
+		t.Errorf("ERROR: expected statuses Unset and Error, got %s and %s", parentSpan.Status.Code, childSpan.Status.Code)
+	}
+	attributes := make(map[string]interface{})
+	for _, a := range childSpan.Attributes {
+		attributes[a.Key] = a.Value.Value
+	}
+	if attributes["account_id"] != "000000000001" || attributes["error_class"] != "unknown" {
+		t.Errorf("ERROR: unexpected child span attributes: %v", attributes)
+	}
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, "test", nil)
+	if err != nil {
+		t.Fatalf("ERROR: could not set up tracing: %s", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("ERROR: expected no error shutting down, got %s", err)
+	}
+	if _, err := Setup(context.Background(), "zipkin", "test", nil); err == nil {
+		t.Error("ERROR: expected an error for an unknown exporter")
+	}
+}
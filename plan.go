@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+// collectionJob is a single account and region to collect findings from
+type collectionJob struct {
+	Team          string        `json:"team"`
+	Account       teams.Account `json:"-"`
+	AccountID     string        `json:"accountId"`
+	Environment   string        `json:"environment"`
+	Region        string        `json:"region"`
+	RoleARN       string        `json:"roleArn"`
+	TeamMapSource string        `json:"teamMapSource"`
+}
+
+// collectionPlan describes everything a collection run will do
+type collectionPlan struct {
+	TeamSources   []string        `json:"teamSources"`
+	Selector      *jobSelector    `json:"selector,omitempty"`
+	Accounts      int             `json:"accounts"`
+	Jobs          []collectionJob `json:"jobs"`
+	OutputFile    string          `json:"outputFile"`
+	OutputFormat  string          `json:"outputFormat"`
+	SummaryFile   string          `json:"summaryFile"`
+	ManifestFile  string          `json:"manifestFile"`
+	S3Bucket      string          `json:"s3Bucket,omitempty"`
+	S3Key         string          `json:"s3Key,omitempty"`
+	SummaryS3Key  string          `json:"summaryS3Key,omitempty"`
+	ManifestS3Key string          `json:"manifestS3Key,omitempty"`
+}
+
+// collectionJobs returns the account and region pairs to collect from, sorted by team, account and region
+func collectionJobs(accountsToTeams map[teams.Account]string, overrides *teams.AccountOverrides, secHubRegions []string) []collectionJob {
+	var jobs []collectionJob
+	for account, teamName := range accountsToTeams {
+		for _, region := range overrides.RegionsFor(account, secHubRegions) {
+			jobs = append(jobs, collectionJob{
+				Team:          teamName,
+				Account:       account,
+				AccountID:     account.ID,
+				Environment:   account.Environment,
+				Region:        region,
+				RoleARN:       account.RoleARN,
+				TeamMapSource: account.Source,
+			})
+		}
+	}
+
+	slices.SortFunc(jobs, func(a, b collectionJob) int {
+		return strings.Compare(
+			strings.Join([]string{a.Team, a.AccountID, a.Region}, "\x00"),
+			strings.Join([]string{b.Team, b.AccountID, b.Region}, "\x00"),
+		)
+	})
+	return jobs
+}
+
+// buildCollectionPlan resolves the team sources and works out the jobs selected by selector, the output
+// file and the S3 key for a collection run, without calling Security Hub or S3
+func buildCollectionPlan(upload bool, selector jobSelector) (*collectionPlan, error) {
+	accountsToTeams, overrides, sources, err := loadTeams()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := selector.selectJobs(collectionJobs(accountsToTeams, overrides, options.SecurityHubRegions))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &collectionPlan{
+		Accounts:     len(jobAccounts(jobs)),
+		Jobs:         jobs,
+		OutputFile:   outputFileName(selector),
+		OutputFormat: options.OutputFormat,
+		SummaryFile:  summaryFileName(selector),
+		ManifestFile: manifestFileName(selector),
+	}
+	if selector.isSubset() {
+		plan.Selector = &selector
+	}
+	for _, source := range sources {
+		plan.TeamSources = append(plan.TeamSources, source.String())
+	}
+	if upload && options.S3Bucket != "" {
+		plan.S3Bucket = options.S3Bucket
+		date := time.Now()
+		plan.S3Key = dailyS3Key(date)
+		plan.SummaryS3Key = summaryS3Key(date)
+		plan.ManifestS3Key = manifestS3Key(date)
+	}
+	return plan, nil
+}
+
+// jobAccounts returns the map of accounts to team names for the accounts in jobs
+func jobAccounts(jobs []collectionJob) map[teams.Account]string {
+	accountsToTeams := map[teams.Account]string{}
+	for _, job := range jobs {
+		accountsToTeams[job.Account] = job.Team
+	}
+	return accountsToTeams
+}
+
+// jobRegions returns the regions of the jobs by account ID
+func jobRegions(jobs []collectionJob) map[string][]string {
+	regions := map[string][]string{}
+	for _, job := range jobs {
+		regions[job.AccountID] = append(regions[job.AccountID], job.Region)
+	}
+	return regions
+}
+
+// write writes the plan as a table or as JSON
+func (p *collectionPlan) write(w io.Writer, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(p)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "team sources:\t%s\n", strings.Join(p.TeamSources, ", "))
+	if p.Selector != nil {
+		fmt.Fprintf(tw, "selected:\t%s\n", p.Selector)
+	}
+	fmt.Fprintf(tw, "output file:\t%s (%s)\n", p.OutputFile, p.OutputFormat)
+	fmt.Fprintf(tw, "summary file:\t%s\n", p.SummaryFile)
+	fmt.Fprintf(tw, "manifest file:\t%s\n", p.ManifestFile)
+	if p.S3Bucket != "" {
+		fmt.Fprintf(tw, "upload to:\ts3://%s/%s\n", p.S3Bucket, p.S3Key)
+		fmt.Fprintf(tw, "\ts3://%s/%s\n", p.S3Bucket, p.SummaryS3Key)
+		fmt.Fprintf(tw, "\ts3://%s/%s\n", p.S3Bucket, p.ManifestS3Key)
+	} else {
+		fmt.Fprintf(tw, "upload to:\t(not uploaded)\n")
+	}
+	fmt.Fprintf(tw, "jobs:\t%d (%d accounts)\n\n", len(p.Jobs), p.Accounts)
+
+	fmt.Fprintln(tw, "TEAM\tACCOUNT\tENVIRONMENT\tREGION\tROLE ARN\tTEAM MAP SOURCE")
+	for _, job := range p.Jobs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", job.Team, job.AccountID, job.Environment, job.Region, job.RoleARN, job.TeamMapSource)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/preflight"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/teams"
+)
+
+// PreflightCommand checks access to every account in the team map and to the S3 bucket
+type PreflightCommand struct {
+	Concurrency int    `long:"concurrency" default:"10" description:"Number of accounts to check at once."`
+	Format      string `long:"format" choice:"table" choice:"json" default:"table" description:"Output format for the results."`
+	EndpointURL string `long:"endpoint-url" description:"Custom endpoint for STS, Security Hub and S3, e.g. a local stub."`
+}
+
+// Execute loads the team map and runs the preflight checks
+func (c *PreflightCommand) Execute(_ []string) error {
+	accountsToTeams, overrides, _, err := loadTeams()
+	if err != nil {
+		return err
+	}
+	return runPreflight(accountsToTeams, overrides, nil, c.Concurrency, c.EndpointURL, c.Format)
+}
+
+// runPreflight runs the preflight checks, writes the results to stdout as a table or JSON,
+// and returns an error if any check failed. If accountRegions is set, only those regions of each
+// account are checked.
+func runPreflight(accountsToTeams map[teams.Account]string, overrides *teams.AccountOverrides, accountRegions map[string][]string, concurrency int, endpointURL, format string) error {
+	checker := preflight.Checker{
+		Regions:        options.SecurityHubRegions,
+		Overrides:      overrides,
+		AccountRegions: accountRegions,
+		S3Bucket:       options.S3Bucket,
+		S3Region:       options.S3Region,
+		Concurrency:    concurrency,
+		EndpointURL:    endpointURL,
+	}
+	if !options.NoPreflightS3Write {
+		checker.S3Key = options.PreflightS3Key
+		if checker.S3Key == "" {
+			checker.S3Key = preflight.DefaultS3Key
+		}
+	}
+	results := checker.Run(context.TODO(), accountsToTeams)
+
+	var err error
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(results)
+	} else {
+		err = preflight.WriteTable(os.Stdout, results)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write preflight results: %v", err)
+	}
+
+	if failed := preflight.Failed(results); failed > 0 {
+		return fmt.Errorf("%d of %d preflight checks failed", failed, len(results))
+	}
+	return nil
+}
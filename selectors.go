@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// jobSelector restricts a collection run to the jobs whose team, account ID and region match one
+// of the respective glob patterns. An empty list of patterns matches everything.
+type jobSelector struct {
+	Teams    []string `json:"teams,omitempty"`
+	Accounts []string `json:"accounts,omitempty"`
+	Regions  []string `json:"regions,omitempty"`
+}
+
+// selectorFromOptions returns the selector given by --team, --account and --region
+func selectorFromOptions() (jobSelector, error) {
+	s := jobSelector{Teams: options.Teams, Accounts: options.Accounts, Regions: options.Regions}
+	return s, s.validate()
+}
+
+// validate checks that all patterns are valid globs
+func (s jobSelector) validate() error {
+	for _, patterns := range [][]string{s.Teams, s.Accounts, s.Regions} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid selector %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isSubset returns true if the selector restricts the run to a subset of the team map
+func (s jobSelector) isSubset() bool {
+	return len(s.Teams) > 0 || len(s.Accounts) > 0 || len(s.Regions) > 0
+}
+
+// matches returns true if the job is selected
+func (s jobSelector) matches(job collectionJob) bool {
+	return matchesAny(s.Teams, job.Team) && matchesAny(s.Accounts, job.AccountID) && matchesAny(s.Regions, job.Region)
+}
+
+// selectJobs returns the jobs the selector matches, or an error if it matches none, since an
+// empty subset run is almost certainly a typo in a selector
+func (s jobSelector) selectJobs(jobs []collectionJob) ([]collectionJob, error) {
+	if !s.isSubset() {
+		return jobs, nil
+	}
+
+	var selected []collectionJob
+	for _, job := range jobs {
+		if s.matches(job) {
+			selected = append(selected, job)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no accounts and regions match %s", s)
+	}
+	return selected, nil
+}
+
+// String describes the selector, e.g. "--team dev-* --region us-east-1"
+func (s jobSelector) String() string {
+	var parts []string
+	for _, selector := range []struct {
+		flag     string
+		patterns []string
+	}{
+		{"--team", s.Teams},
+		{"--account", s.Accounts},
+		{"--region", s.Regions},
+	} {
+		for _, pattern := range selector.patterns {
+			parts = append(parts, selector.flag+" "+pattern)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// matchesAny returns true if there are no patterns or value matches one of them
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// outputFileName returns the file to write findings to. Subset runs write to a separate file
+// so that they don't clobber the output of a full run: --subset-output if it is set, or the
+// output file name with a "_subset" suffix.
+func outputFileName(selector jobSelector) string {
+	if !selector.isSubset() {
+		return options.OutputFileName
+	}
+	if options.SubsetOutputFileName != "" {
+		return options.SubsetOutputFileName
+	}
+	ext := path.Ext(options.OutputFileName)
+	return strings.TrimSuffix(options.OutputFileName, ext) + "_subset" + ext
+}
+
+// summaryFileName returns the file to write the JSON run summary to: --summary-output if it is
+// set, or the output file name with a "_summary.json" suffix
+func summaryFileName(selector jobSelector) string {
+	if options.SummaryFileName != "" {
+		return options.SummaryFileName
+	}
+	fileName := outputFileName(selector)
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + "_summary.json"
+}
+
+// manifestFileName returns the file to write the JSON run manifest to: --manifest-output if it is
+// set, or the output file name with a "_manifest.json" suffix
+func manifestFileName(selector jobSelector) string {
+	if options.ManifestFileName != "" {
+		return options.ManifestFileName
+	}
+	fileName := outputFileName(selector)
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + "_manifest.json"
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/benbjohnson/clock"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/internal/aws/client"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/api"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/helpers"
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/securityhubcollector"
+)
+
+// ServeCommand serves a read-only REST API over the latest findings
+type ServeCommand struct {
+	Addr       string `long:"addr" default:":8080" description:"Address to serve the API on."`
+	File       string `long:"file" description:"Findings file to serve. Defaults to the output file."`
+	FileFormat string `long:"file-format" choice:"tsv" choice:"csv" choice:"jsonl" description:"Format of the findings file. Defaults to --output-format for the output file, and is otherwise guessed from the file extension."`
+	FromS3     bool   `long:"from-s3" description:"Serve the latest findings file uploaded to the S3 bucket instead of a local file."`
+}
+
+// Execute loads the findings file and serves the API until the process is stopped
+func (c *ServeCommand) Execute(_ []string) error {
+	dataset, err := c.loadDataset(context.Background())
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              c.Addr,
+		Handler:           api.NewServer(dataset, clock.New()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("serving findings", "addr", c.Addr, "source", dataset.Source, "rows", len(dataset.Rows))
+	return server.ListenAndServe()
+}
+
+// loadDataset reads the findings file to serve, downloading the latest one from S3 with --from-s3
+func (c *ServeCommand) loadDataset(ctx context.Context) (api.Dataset, error) {
+	fileName := c.File
+	if fileName == "" {
+		fileName = options.OutputFileName
+	}
+	source := fileName
+
+	if c.FromS3 {
+		if options.S3Bucket == "" {
+			return api.Dataset{}, fmt.Errorf("--from-s3 requires an S3 bucket")
+		}
+		key, err := latestDailyS3Key(ctx)
+		if err != nil {
+			return api.Dataset{}, err
+		}
+		dir, err := os.MkdirTemp("", "security-hub-collector")
+		if err != nil {
+			return api.Dataset{}, err
+		}
+		defer os.RemoveAll(dir) //nolint
+
+		fileName = filepath.Join(dir, path.Base(key))
+		err = downloadFromS3(ctx, key, fileName)
+		if err != nil {
+			return api.Dataset{}, fmt.Errorf("could not download findings from S3: %v", err)
+		}
+		source = fmt.Sprintf("s3://%s/%s", options.S3Bucket, key)
+	}
+
+	fileFormat := findingsFileFormat(fileName, c.FileFormat, c.File == "" || c.FromS3)
+	headers, rows, err := securityhubcollector.ReadFindingsFile(fileName, fileFormat)
+	if err != nil {
+		return api.Dataset{}, err
+	}
+	return api.Dataset{Source: source, LoadedAt: time.Now().UTC(), Headers: headers, Rows: rows}, nil
+}
+
+// latestDailyS3Key returns the most recent daily key that findings were uploaded to
+func latestDailyS3Key(ctx context.Context) (string, error) {
+	s3Client, err := client.MakeS3Client(options.S3Region)
+	if err != nil {
+		return "", fmt.Errorf("unable to load SDK config for S3: %s", err)
+	}
+
+	// daily keys are the findings key with a date suffix; see dailyS3Key
+	prefix, ext := dailyS3KeyParts()
+	var latestKey string
+	var latestDate time.Time
+	paginator := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(options.S3Bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("could not list findings in S3: %v", err)
+		}
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			if !strings.HasSuffix(key, ext) {
+				continue
+			}
+			date, err := time.Parse("01-02-2006", strings.TrimSuffix(strings.TrimPrefix(key, prefix), ext))
+			if err != nil {
+				// the run summary, manifest or another file
+				continue
+			}
+			if latestKey == "" || date.After(latestDate) {
+				latestKey, latestDate = key, date
+			}
+		}
+	}
+	if latestKey == "" {
+		return "", fmt.Errorf("no findings found in s3://%s/%s*%s", options.S3Bucket, prefix, ext)
+	}
+	return latestKey, nil
+}
+
+// dailyS3KeyParts returns the parts of the daily S3 key before and after the date
+func dailyS3KeyParts() (string, string) {
+	key := dailyS3Key(time.Time{})
+	ext := path.Ext(key)
+	date := time.Time{}.Format("01-02-2006")
+	return strings.TrimSuffix(key, date+ext), ext
+}
+
+// downloadFromS3 downloads an object from the S3 bucket to fileName
+func downloadFromS3(ctx context.Context, key, fileName string) (err error) {
+	s3Client, err := client.MakeS3Client(options.S3Region)
+	if err != nil {
+		return fmt.Errorf("unable to load SDK config for S3: %s", err)
+	}
+	out, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(options.S3Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+	defer out.Body.Close() //nolint
+
+	f, err := os.Create(filepath.Clean(fileName))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := f.Close()
+		if cerr != nil {
+			err = helpers.CombineErrors(err, cerr)
+		}
+	}()
+
+	_, err = io.Copy(f, out.Body)
+	if err == nil {
+		slog.Info("downloaded findings from S3", "bucket", options.S3Bucket, "key", key)
+	}
+	return err
+}
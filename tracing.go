@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/Enterprise-CMCS/security-hub-collector/pkg/tracing"
+)
+
+// startTracing sets up the --trace-exporter and returns a function that flushes the spans that
+// haven't been exported yet
+func startTracing(ctx context.Context) (func(), error) {
+	shutdown, err := tracing.Setup(ctx, options.TraceExporter, version, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := shutdown(context.Background()); err != nil {
+			slog.Warn("could not export spans", "error", err)
+		}
+	}, nil
+}